@@ -3,3 +3,5 @@ module github.com/psanford/gsm
 go 1.20
 
 require github.com/google/go-cmp v0.5.9
+
+require golang.org/x/text v0.14.0
@@ -0,0 +1,156 @@
+// Package gsm7 packs and unpacks text in the GSM 7 bit default
+// alphabet of 3GPP TS 23.038 (formerly GSM 03.38), the encoding of most
+// SMS user data.
+//
+// Characters are encoded as 7 bit septets, with those of the extension
+// table, such as '{' and '€', written as an escape septet followed by
+// a second septet. Septets are packed eight to every seven octets,
+// least significant bit first.
+package gsm7
+
+import "strings"
+
+// escape introduces a character from the extension table.
+const escape = 0x1b
+
+// basic is the default alphabet, indexed by septet. The escape
+// septet decodes as a space where it isn't followed by another septet.
+var basic = [128]rune{
+	'@', '£', '$', '¥', 'è', 'é', 'ù', 'ì', 'ò', 'Ç', '\n', 'Ø', 'ø', '\r', 'Å', 'å',
+	'Δ', '_', 'Φ', 'Γ', 'Λ', 'Ω', 'Π', 'Ψ', 'Σ', 'Θ', 'Ξ', ' ', 'Æ', 'æ', 'ß', 'É',
+	' ', '!', '"', '#', '¤', '%', '&', '\'', '(', ')', '*', '+', ',', '-', '.', '/',
+	'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', ':', ';', '<', '=', '>', '?',
+	'¡', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O',
+	'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', 'Ä', 'Ö', 'Ñ', 'Ü', '§',
+	'¿', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o',
+	'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', 'ä', 'ö', 'ñ', 'ü', 'à',
+}
+
+// extension is the default alphabet extension table, indexed by the
+// septet that follows an escape.
+var extension = map[byte]rune{
+	0x0a: '\f',
+	0x14: '^',
+	0x28: '{',
+	0x29: '}',
+	0x2f: '\\',
+	0x3c: '[',
+	0x3d: '~',
+	0x3e: ']',
+	0x40: '|',
+	0x65: '€',
+}
+
+// basicSeptet and extensionSeptet map characters back to septets.
+var (
+	basicSeptet     = make(map[rune]byte, len(basic))
+	extensionSeptet = make(map[rune]byte, len(extension))
+)
+
+func init() {
+	for i, r := range basic {
+		if i == escape {
+			continue
+		}
+		basicSeptet[r] = byte(i)
+	}
+	for septet, r := range extension {
+		extensionSeptet[r] = septet
+	}
+}
+
+// Unpack decodes the first septets septets packed in data. If data
+// holds fewer, as many as it holds are decoded.
+//
+// An escape followed by a septet the extension table doesn't define
+// decodes as that septet's character in the default alphabet, as TS
+// 23.038 directs.
+func Unpack(data []byte, septets int) string {
+	if max := len(data) * 8 / 7; septets > max {
+		septets = max
+	}
+
+	var b strings.Builder
+	escaped := false
+	for i := 0; i < septets; i++ {
+		c := septet(data, i)
+		if escaped {
+			escaped = false
+			if r, ok := extension[c]; ok {
+				b.WriteRune(r)
+				continue
+			}
+		} else if c == escape {
+			escaped = true
+			continue
+		}
+		b.WriteRune(basic[c])
+	}
+	if escaped {
+		b.WriteRune(basic[escape])
+	}
+	return b.String()
+}
+
+// septet returns the ith septet packed in data.
+func septet(data []byte, i int) byte {
+	bit := i * 7
+	idx, shift := bit/8, bit%8
+	c := data[idx] >> shift
+	if shift > 1 && idx+1 < len(data) {
+		c |= data[idx+1] << (8 - shift)
+	}
+	return c & 0x7f
+}
+
+// SeptetLen returns the number of septets r takes in the default
+// alphabet: 1 for the basic character set, 2 for the extension table,
+// or 0 if the alphabet can't represent it.
+func SeptetLen(r rune) int {
+	if _, ok := basicSeptet[r]; ok {
+		return 1
+	}
+	if _, ok := extensionSeptet[r]; ok {
+		return 2
+	}
+	return 0
+}
+
+// Encodable reports whether every character of s is in the default
+// alphabet or its extension table.
+func Encodable(s string) bool {
+	for _, r := range s {
+		if SeptetLen(r) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Pack encodes s in the default alphabet and packs it, returning the
+// packed octets and the number of septets they hold. Characters in the
+// extension table take two septets. Characters the alphabet can't
+// represent are encoded as '?'.
+func Pack(s string) ([]byte, int) {
+	var septets []byte
+	for _, r := range s {
+		if c, ok := basicSeptet[r]; ok {
+			septets = append(septets, c)
+		} else if c, ok := extensionSeptet[r]; ok {
+			septets = append(septets, escape, c)
+		} else {
+			septets = append(septets, basicSeptet['?'])
+		}
+	}
+
+	out := make([]byte, (len(septets)*7+7)/8)
+	for i, c := range septets {
+		bit := i * 7
+		idx, shift := bit/8, bit%8
+		out[idx] |= c << shift
+		if shift > 1 {
+			out[idx+1] |= c >> (8 - shift)
+		}
+	}
+	return out, len(septets)
+}
@@ -0,0 +1,110 @@
+package gsm7
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPackUnpack(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		packed  string
+		septets int
+		// unpacked is the text Unpack returns, if not text
+		unpacked string
+	}{
+		{"", "", 0, ""},
+		{"hello", "e8329bfd06", 5, ""},
+		{"hellohello", "e8329bfd4697d9ec37", 10, ""},
+		// eight septets fill seven octets exactly
+		{"12345678", "31d98c56b3dd70", 8, ""},
+		{"€", "9b32", 2, ""},
+		{"{a}", "1b54789302", 5, ""},
+		{"Ünïcode?", "5ef76ffc26977f", 8, "Ün?code?"},
+		{"@£$¥", "80806000", 4, ""},
+	} {
+		packed, septets := Pack(tc.text)
+		if got := hex.EncodeToString(packed); got != tc.packed || septets != tc.septets {
+			t.Errorf("Pack(%q) = %s, %d want %s, %d", tc.text, got, septets, tc.packed, tc.septets)
+		}
+
+		data, err := hex.DecodeString(tc.packed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tc.text
+		if tc.unpacked != "" {
+			want = tc.unpacked
+		}
+		if got := Unpack(data, tc.septets); got != want {
+			t.Errorf("Unpack(%s, %d) = %q want %q", tc.packed, tc.septets, got, want)
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	data, _ := hex.DecodeString("e8329bfd06")
+	if got := Unpack(data, 100); got != "hello" {
+		t.Errorf("septets past the data: got %q", got)
+	}
+	if got := Unpack(data, 3); got != "hel" {
+		t.Errorf("short septet count: got %q", got)
+	}
+
+	// an escape before a septet with no extension falls back to the
+	// default alphabet, and a trailing escape is a space
+	packed, n := packSeptets(0x1b, 0x41, 0x1b)
+	if got := Unpack(packed, n); got != "A " {
+		t.Errorf("unknown extension: got %q", got)
+	}
+}
+
+func TestRoundTripAlphabet(t *testing.T) {
+	var all []rune
+	for i, r := range basic {
+		if i != escape {
+			all = append(all, r)
+		}
+	}
+	for _, r := range extension {
+		all = append(all, r)
+	}
+	packed, n := Pack(string(all))
+	if got := Unpack(packed, n); got != string(all) {
+		t.Errorf("round trip got %q want %q", got, string(all))
+	}
+}
+
+// packSeptets packs raw septets.
+func packSeptets(septets ...byte) ([]byte, int) {
+	out := make([]byte, (len(septets)*7+7)/8)
+	for i, c := range septets {
+		bit := i * 7
+		idx, shift := bit/8, bit%8
+		out[idx] |= c << shift
+		if shift > 1 {
+			out[idx+1] |= c >> (8 - shift)
+		}
+	}
+	return out, len(septets)
+}
+
+func TestEncodable(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"Hello, World!", true},
+		{"price: 5€ [ok]", true},
+		{"naïve", false},
+		{"你好", false},
+	} {
+		if got := Encodable(tc.s); got != tc.want {
+			t.Errorf("Encodable(%q) = %v want %v", tc.s, got, tc.want)
+		}
+	}
+	if SeptetLen('a') != 1 || SeptetLen('€') != 2 || SeptetLen('ï') != 0 {
+		t.Errorf("unexpected SeptetLen")
+	}
+}
@@ -0,0 +1,261 @@
+package mms
+
+import (
+	"strings"
+	"time"
+)
+
+// From returns the sender address of the message, or "" if absent. A
+// From left for the MMSC to insert is returned as InsertAddressToken;
+// use FromInsert to tell it apart from an address with that text.
+func (m *Message) From() string {
+	if v := m.field(From); v != nil {
+		return v.String()
+	}
+	return ""
+}
+
+// FromInsert reports whether the From field carries the
+// Insert-address-token, asking the MMSC to insert the sender's address.
+func (m *Message) FromInsert() bool {
+	v := m.field(From)
+	return v != nil && headerFrom(v).Insert
+}
+
+// headerFrom returns a From value as a HeaderFrom. A HeaderString
+// holding InsertAddressToken, as messages built before HeaderFrom
+// existed used, is taken as the Insert-address-token.
+func headerFrom(v HeaderField) HeaderFrom {
+	if hf, ok := v.(*HeaderFrom); ok {
+		return *hf
+	}
+	s := v.String()
+	if s == InsertAddressToken {
+		return HeaderFrom{Insert: true}
+	}
+	return HeaderFrom{Address: s}
+}
+
+// To returns the To addresses of the message.
+func (m *Message) To() []string {
+	return m.stringFields(To)
+}
+
+// Cc returns the Cc addresses of the message.
+func (m *Message) Cc() []string {
+	return m.stringFields(Cc)
+}
+
+// AllValues returns the String form of every value decoded for field,
+// in the order they appeared. Repeatable fields such as To, Cc and Bcc
+// may have several values.
+func (m *Message) AllValues(field MMSField) []string {
+	var out []string
+	for _, v := range m.Header[field] {
+		out = append(out, v.String())
+	}
+	return out
+}
+
+// Subject returns the subject of the message, or "" if absent.
+func (m *Message) Subject() string {
+	return m.stringField(Subject)
+}
+
+// Date returns the Date of the message and whether the field was
+// present.
+func (m *Message) Date() (time.Time, bool) {
+	if d, ok := m.field(Date).(*HeaderTime); ok {
+		return time.Time(*d), true
+	}
+	return time.Time{}, false
+}
+
+// ExpiresAt returns the X-Mms-Expiry of the message as an absolute
+// time, resolving a relative expiry from received, and whether the
+// field was present.
+func (m *Message) ExpiresAt(received time.Time) (time.Time, bool) {
+	if e, ok := m.field(Expiry).(*HeaderRelativeOrAbsoluteTime); ok {
+		return e.ResolveAbsolute(received), true
+	}
+	return time.Time{}, false
+}
+
+// MessageType returns the X-Mms-Message-Type of the message, or
+// UnknownMessageType if absent.
+func (m *Message) MessageType() HeaderMessageType {
+	if t, ok := m.field(MessageType).(*HeaderMessageType); ok {
+		return *t
+	}
+	return UnknownMessageType
+}
+
+// ContentType returns the top level Content-Type of the message, or
+// "" if absent.
+func (m *Message) ContentType() string {
+	return m.stringField(ContentType)
+}
+
+// ContentTypeParam returns the parameter p of the top level
+// Content-Type, such as the StartParam identifying the root part of a
+// multipart/related message, or "" if absent. The parameters are held
+// in m.ContentTypeParams.
+func (m *Message) ContentTypeParam(p WellKnownParam) string {
+	return m.ContentTypeParams[p]
+}
+
+// Priority returns the X-Mms-Priority of the message. Per WAP-209 an
+// absent priority means Normal, so Medium is returned when the field
+// is not present. Use HasPriority to tell the two cases apart.
+func (m *Message) Priority() HeaderPriority {
+	if p, ok := m.field(Priority).(*HeaderPriority); ok {
+		return *p
+	}
+	return Medium
+}
+
+// HasPriority reports whether the message explicitly carries an
+// X-Mms-Priority field.
+func (m *Message) HasPriority() bool {
+	_, ok := m.field(Priority).(*HeaderPriority)
+	return ok
+}
+
+// ApplicID returns the X-Mms-Applic-ID identifying the application
+// the message is addressed to, or "" if absent.
+func (m *Message) ApplicID() string {
+	return m.stringField(ApplicID)
+}
+
+// ReplyApplicID returns the X-Mms-Reply-Applic-ID that replies to the
+// message should be routed to, or "" if absent.
+func (m *Message) ReplyApplicID() string {
+	return m.stringField(ReplyApplicID)
+}
+
+// AuxApplicInfo returns the X-Mms-Aux-Applic-Info auxiliary
+// application data, or "" if absent.
+func (m *Message) AuxApplicInfo() string {
+	return m.stringField(AuxApplicInfo)
+}
+
+// IsReadReport reports whether the message is a read report, either
+// the m-read-rec-ind sent by the recipient's client or the
+// m-read-orig-ind delivered to the originator.
+func (m *Message) IsReadReport() bool {
+	typ, ok := m.field(MessageType).(*HeaderMessageType)
+	if !ok {
+		return false
+	}
+	return *typ == MReadRecInd || *typ == MReadOrigInd
+}
+
+// ReferencedMessageID returns the Message-ID of the original message a
+// read report refers to, or "" if the message is not a read report.
+func (m *Message) ReferencedMessageID() string {
+	if !m.IsReadReport() {
+		return ""
+	}
+	return m.stringField(MessageID)
+}
+
+// ReadStatus returns the X-Mms-Read-Status of a read report and
+// whether the field was present.
+func (m *Message) ReadStatus() (HeaderReadStatus, bool) {
+	if s, ok := m.field(ReadStatus).(*HeaderReadStatus); ok {
+		return *s, true
+	}
+	return 0, false
+}
+
+// CancelID returns the X-Mms-Cancel-ID naming the message an
+// m-cancel-req refers to, or "" if absent.
+func (m *Message) CancelID() string {
+	return m.stringField(CancelID)
+}
+
+// CancelStatus returns the X-Mms-Cancel-Status of an m-cancel-conf and
+// whether the field was present.
+func (m *Message) CancelStatus() (HeaderCancelStatus, bool) {
+	if s, ok := m.field(CancelStatus).(*HeaderCancelStatus); ok {
+		return *s, true
+	}
+	return 0, false
+}
+
+// PresentationPart returns the SMIL presentation part of the message,
+// if it has one.
+func (m *Message) PresentationPart() (*PDUPart, bool) {
+	for i := range m.Parts {
+		if m.Parts[i].IsPresentation() {
+			return &m.Parts[i], true
+		}
+	}
+	return nil, false
+}
+
+// PartsByContentType returns the parts whose media type is contentType
+// or, when it ends in "/" or "/*" such as "image/", falls under it.
+// Letter case and content type parameters are ignored.
+func (m *Message) PartsByContentType(contentType string) []*PDUPart {
+	want := strings.TrimSuffix(mediaType(contentType), "*")
+	prefix := strings.HasSuffix(want, "/")
+
+	var out []*PDUPart
+	for i := range m.Parts {
+		mt := mediaType(m.Parts[i].ContentType)
+		if mt == want || prefix && strings.HasPrefix(mt, want) {
+			out = append(out, &m.Parts[i])
+		}
+	}
+	return out
+}
+
+// stringField returns the first value of f if it was decoded as a
+// HeaderString, or "" otherwise.
+func (m *Message) stringField(f MMSField) string {
+	if s, ok := m.field(f).(*HeaderString); ok {
+		return string(*s)
+	}
+	return ""
+}
+
+// stringFields returns every value of f decoded as a HeaderString.
+func (m *Message) stringFields(f MMSField) []string {
+	var out []string
+	for _, v := range m.Header[f] {
+		if s, ok := v.(*HeaderString); ok {
+			out = append(out, string(*s))
+		}
+	}
+	return out
+}
+
+// field returns the first value decoded for f, or nil if f is absent.
+func (m *Message) field(f MMSField) HeaderField {
+	vals := m.Header[f]
+	if len(vals) == 0 {
+		return nil
+	}
+	return vals[0]
+}
+
+// Version returns the X-Mms-MMS-Version of the message and whether
+// the field was present. Legacy PDUs that omit it are reported as
+// version "1.0".
+func (m *Message) Version() (string, bool) {
+	if v := m.stringField(MMSVersion); v != "" {
+		return v, true
+	}
+	return "1.0", false
+}
+
+// AdaptationAllowed returns the X-Mms-Adaptation-Allowed value and
+// whether the field was present. When absent the MMSC's own policy
+// decides whether content may be adapted.
+func (m *Message) AdaptationAllowed() (bool, bool) {
+	if b, ok := m.field(AdaptationAllowed).(*HeaderBool); ok {
+		return bool(*b), true
+	}
+	return false, false
+}
@@ -0,0 +1,404 @@
+package mms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPriority(t *testing.T) {
+	msg, err := Unmarshal([]byte{0x8c, 0x80, 0x8d, 0x92})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.HasPriority() {
+		t.Fatal("expected no priority")
+	}
+	if p := msg.Priority(); p != Medium {
+		t.Fatalf("priority got %s want medium", &p)
+	}
+
+	msg, err = Unmarshal([]byte{0x8c, 0x80, 0x8d, 0x92, 0x8f, 0x82})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !msg.HasPriority() {
+		t.Fatal("expected priority")
+	}
+	if p := msg.Priority(); p != High {
+		t.Fatalf("priority got %s want high", &p)
+	}
+}
+
+func TestAdaptationAllowed(t *testing.T) {
+	msg, err := Unmarshal([]byte{0x8c, 0x84, 0x8d, 0x93})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg.AdaptationAllowed(); ok {
+		t.Fatal("expected adaptation-allowed to be absent")
+	}
+
+	msg, err = Unmarshal([]byte{0x8c, 0x84, 0x8d, 0x93, 0xbc, 0x81})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed, ok := msg.AdaptationAllowed()
+	if !ok || allowed {
+		t.Fatalf("got allowed=%t present=%t, want false true", allowed, ok)
+	}
+}
+
+func TestApplicID(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8d, 0x93, // 1.3
+		0xb7, 'c', 'o', 'm', '.', 'a', 0x00, // X-Mms-Applic-ID
+		0xb8, 'c', 'o', 'm', '.', 'b', 0x00, // X-Mms-Reply-Applic-ID
+		0xb9, 'x', '=', '1', 0x00, // X-Mms-Aux-Applic-Info
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := msg.ApplicID(); got != "com.a" {
+		t.Errorf("applic-id got %q", got)
+	}
+	if got := msg.ReplyApplicID(); got != "com.b" {
+		t.Errorf("reply-applic-id got %q", got)
+	}
+	if got := msg.AuxApplicInfo(); got != "x=1" {
+		t.Errorf("aux-applic-info got %q", got)
+	}
+}
+
+func TestReadReport(t *testing.T) {
+	for _, typ := range []byte{0x87, 0x88} {
+		msg, err := Unmarshal([]byte{
+			0x8c, typ, // m-read-rec-ind / m-read-orig-ind
+			0x8d, 0x91, // 1.1
+			0x8b, 'i', 'd', '1', 0x00, // Message-ID: id1
+			0x97, '+', '1', '5', '5', '5', '/', 'T', 'Y', 'P', 'E', '=', 'P', 'L', 'M', 'N', 0x00, // To
+			0x89, 0x01, 0x81, // From: insert-address-token
+			0x9b, 0x81, // X-Mms-Read-Status: deleted without being read
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !msg.IsReadReport() {
+			t.Fatalf("0x%x: expected read report", typ)
+		}
+		if got := msg.ReferencedMessageID(); got != "id1" {
+			t.Errorf("0x%x: referenced message id got %q", typ, got)
+		}
+		status, ok := msg.ReadStatus()
+		if !ok || status != ReadStatusDeletedWithoutBeingRead {
+			t.Errorf("0x%x: read status got %d %t", typ, status, ok)
+		}
+	}
+
+	msg, err := Unmarshal([]byte{0x8c, 0x84, 0x8b, 'i', 'd', 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.IsReadReport() || msg.ReferencedMessageID() != "" {
+		t.Fatal("m-retrieve-conf reported as read report")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0x8c, 0x96, // m-cancel-req
+		0x98, 't', 0x00, // X-Mms-Transaction-Id
+		0x8d, 0x93, // 1.3
+		0xbe, 'm', 's', 'g', '1', 0x00, // X-Mms-Cancel-ID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := msg.Header[MessageType][0].String(); typ != "m-cancel-req" {
+		t.Errorf("message type got %q", typ)
+	}
+	if got := msg.CancelID(); got != "msg1" {
+		t.Errorf("cancel id got %q", got)
+	}
+
+	msg, err = Unmarshal([]byte{
+		0x8c, 0x97, // m-cancel-conf
+		0x98, 't', 0x00, // X-Mms-Transaction-Id
+		0x8d, 0x93, // 1.3
+		0xbf, 0x81, // X-Mms-Cancel-Status: corrupted
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := msg.Header[MessageType][0].String(); typ != "m-cancel-conf" {
+		t.Errorf("message type got %q", typ)
+	}
+	status, ok := msg.CancelStatus()
+	if !ok || status != CancelRequestCorrupted {
+		t.Errorf("cancel status got %d %t", status, ok)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 0x00, // X-Mms-Transaction-Id
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01, 0x01, 0x01, 0x83, 'a',
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := msg.Version(); v != "1.0" || ok {
+		t.Fatalf("got %q %t, want 1.0 false", v, ok)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+
+	msg, err = Unmarshal([]byte{0x8c, 0x84, 0x8d, 0x93})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := msg.Version(); v != "1.3" || !ok {
+		t.Fatalf("got %q %t, want 1.3 true", v, ok)
+	}
+}
+
+func TestCommonAccessors(t *testing.T) {
+	var empty Message
+	if empty.From() != "" || empty.To() != nil || empty.Cc() != nil || empty.Subject() != "" || empty.ContentType() != "" {
+		t.Errorf("expected zero values from an empty message")
+	}
+	if _, ok := empty.Date(); ok {
+		t.Errorf("expected no date")
+	}
+	if empty.MessageType() != UnknownMessageType {
+		t.Errorf("expected unknown message type")
+	}
+
+	typ := MRetrieveConf
+	date := HeaderTime(time.Unix(1700000000, 0))
+	msg := Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {&typ},
+			From:        {hs("+15555550100/TYPE=PLMN")},
+			To:          {hs("a@example.com"), hs("b@example.com")},
+			Cc:          {hs("c@example.com")},
+			Subject:     {hs("hi")},
+			Date:        {&date},
+			ContentType: {hs("application/vnd.wap.multipart.related")},
+		},
+	}
+
+	if got := msg.From(); got != "+15555550100/TYPE=PLMN" {
+		t.Errorf("From got %q", got)
+	}
+	if diff := cmp.Diff([]string{"a@example.com", "b@example.com"}, msg.To()); diff != "" {
+		t.Errorf("To mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"c@example.com"}, msg.Cc()); diff != "" {
+		t.Errorf("Cc mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a@example.com", "b@example.com"}, msg.AllValues(To)); diff != "" {
+		t.Errorf("AllValues(To) mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"m-retrieve-conf"}, msg.AllValues(MessageType)); diff != "" {
+		t.Errorf("AllValues(MessageType) mismatch (-want +got):\n%s", diff)
+	}
+	if got := msg.AllValues(Bcc); got != nil {
+		t.Errorf("AllValues(Bcc) got %q", got)
+	}
+	if got := msg.Subject(); got != "hi" {
+		t.Errorf("Subject got %q", got)
+	}
+	if got, ok := msg.Date(); !ok || !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Date got %s, %t", got, ok)
+	}
+	if got := msg.MessageType(); got != MRetrieveConf {
+		t.Errorf("MessageType got %d", got)
+	}
+	if got := msg.ContentType(); got != "application/vnd.wap.multipart.related" {
+		t.Errorf("ContentType got %q", got)
+	}
+}
+
+func TestPartsByContentType(t *testing.T) {
+	msg := &Message{
+		Parts: []PDUPart{
+			{ContentType: "application/smil"},
+			{ContentType: "image/jpeg"},
+			{ContentType: "text/plain; charset=utf-8"},
+			{ContentType: "IMAGE/PNG"},
+			{ContentType: "text/plainish"},
+		},
+	}
+
+	for _, tc := range []struct {
+		contentType string
+		want        []int
+	}{
+		{"image/", []int{1, 3}},
+		{"image/*", []int{1, 3}},
+		{"text/plain", []int{2}},
+		{"Text/Plain; charset=us-ascii", []int{2}},
+		{"video/", nil},
+	} {
+		var got []int
+		for _, p := range msg.PartsByContentType(tc.contentType) {
+			for i := range msg.Parts {
+				if p == &msg.Parts[i] {
+					got = append(got, i)
+				}
+			}
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s: parts mismatch (-want +got):\n%s", tc.contentType, diff)
+		}
+	}
+}
+
+func TestFromInsert(t *testing.T) {
+	insert := []byte{
+		0x8c, 0x80, // m-send-req
+		0x89, 0x01, 0x81, // From: Insert-address-token
+	}
+	literal := append([]byte{
+		0x8c, 0x80, // m-send-req
+		0x89, 0x1a, 0x80, // From: Address-present-token
+		0x18, 0xea, // UTF-8 Encoded-string-value
+	}, "<insert-address-token>\x00"...)
+
+	for _, check := range []struct {
+		packet []byte
+		want   HeaderFrom
+	}{
+		{insert, HeaderFrom{Insert: true}},
+		{literal, HeaderFrom{Address: InsertAddressToken}},
+	} {
+		msg, err := Unmarshal(check.packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := msg.Header[From][0].(*HeaderFrom)
+		if !ok || *got != check.want {
+			t.Errorf("%x: From got %#v", check.packet, msg.Header[From][0])
+		}
+		if msg.FromInsert() != check.want.Insert {
+			t.Errorf("%x: FromInsert got %t", check.packet, msg.FromInsert())
+		}
+		if msg.From() != InsertAddressToken {
+			t.Errorf("%x: From() got %q", check.packet, msg.From())
+		}
+
+		packet, err := Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(check.packet, packet); diff != "" {
+			t.Errorf("re-encoding mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// A HeaderString holding the token is still taken as the
+	// Insert-address-token.
+	msg := &Message{Header: map[MMSField][]HeaderField{From: {hs(InsertAddressToken)}}}
+	if !msg.FromInsert() {
+		t.Errorf("HeaderString token not taken as insert")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	typ := MRetrieveConf
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {&typ},
+			To:          {hs("a"), hs("b")},
+			Subject:     {hs("s")},
+			ContentType: {hs("application/vnd.wap.multipart.mixed")},
+		},
+		Parts: []PDUPart{
+			{ContentType: "text/plain"},
+			{ContentType: "image/jpeg"},
+			{ContentType: "image/png"},
+		},
+	}
+
+	var fields []string
+	err := msg.Walk(func(f MMSField, v HeaderField) error {
+		fields = append(fields, f.String()+"="+v.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Message-Type=m-retrieve-conf", "Subject=s", "To=a", "To=b", "Content-Type=application/vnd.wap.multipart.mixed"}
+	if diff := cmp.Diff(want, fields); diff != "" {
+		t.Errorf("fields mismatch (-want +got):\n%s", diff)
+	}
+
+	stop := errors.New("stop")
+	var n int
+	err = msg.Walk(func(f MMSField, v HeaderField) error {
+		n++
+		if f == To {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("Walk got %v after %d fields", err, n)
+	}
+
+	var types []string
+	err = msg.WalkParts(func(i int, p *PDUPart) error {
+		types = append(types, p.ContentType)
+		if i == 1 {
+			p.FileName = "photo.jpg"
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("WalkParts got %v", err)
+	}
+	if diff := cmp.Diff([]string{"text/plain", "image/jpeg"}, types); diff != "" {
+		t.Errorf("parts mismatch (-want +got):\n%s", diff)
+	}
+	if msg.Parts[1].FileName != "photo.jpg" {
+		t.Errorf("WalkParts change not made to the message")
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	abs := time.Unix(1700086400, 0)
+	rel := 2 * time.Hour
+
+	checks := []struct {
+		name  string
+		value HeaderField
+		want  time.Time
+	}{
+		{"absolute", &HeaderRelativeOrAbsoluteTime{Absolute: &abs}, abs},
+		{"relative", &HeaderRelativeOrAbsoluteTime{Relative: &rel}, received.Add(rel)},
+	}
+
+	for _, check := range checks {
+		msg := &Message{Header: map[MMSField][]HeaderField{Expiry: {check.value}}}
+		got, ok := msg.ExpiresAt(received)
+		if !ok || !got.Equal(check.want) {
+			t.Errorf("%s: got %s, %t want %s", check.name, got, ok, check.want)
+		}
+	}
+
+	var empty Message
+	if _, ok := empty.ExpiresAt(received); ok {
+		t.Errorf("expected no expiry")
+	}
+}
@@ -0,0 +1,61 @@
+package mms
+
+import "strings"
+
+// Address types defined by WAP-209 section 8. Other values name
+// device specific address types.
+const (
+	PLMNAddress   = "PLMN"
+	IPv4Address   = "IPv4"
+	IPv6Address   = "IPv6"
+	RFC822Address = "RFC822"
+)
+
+// Address is an MMS address split into its value and type.
+//
+//	address = ( e-mail / device-address / alphanum-shortcode / num-shortcode )
+//	device-address = ( global-phone-number "/TYPE=PLMN" )
+//	    / ( ipv4 "/TYPE=IPv4" ) / ( ipv6 "/TYPE=IPv6" )
+//	    / ( escaped-value "/TYPE=" address-type )
+type Address struct {
+	Value string
+	Type  string
+}
+
+// ParseAddress splits an encoded address at its "/TYPE=" suffix. An
+// address without the suffix is an e-mail address if it contains an
+// "@", as the grammar leaves those untyped, and has an empty Type
+// otherwise.
+func ParseAddress(s string) Address {
+	const suffix = "/type="
+	if i := strings.LastIndex(strings.ToLower(s), suffix); i >= 0 {
+		return Address{
+			Value: s[:i],
+			Type:  s[i+len(suffix):],
+		}
+	}
+	if strings.Contains(s, "@") {
+		return Address{Value: s, Type: RFC822Address}
+	}
+	return Address{Value: s}
+}
+
+// String returns the address in its encoded form.
+func (a Address) String() string {
+	if a.Type == "" || a.Type == RFC822Address && strings.Contains(a.Value, "@") {
+		return a.Value
+	}
+	return a.Value + "/TYPE=" + a.Type
+}
+
+// Recipients returns the parsed To, Cc and Bcc addresses of the
+// message, in that order. The raw header values are left unchanged.
+func (m *Message) Recipients() []Address {
+	var out []Address
+	for _, f := range []MMSField{To, Cc, Bcc} {
+		for _, s := range m.stringFields(f) {
+			out = append(out, ParseAddress(s))
+		}
+	}
+	return out
+}
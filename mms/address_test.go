@@ -0,0 +1,38 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecipients(t *testing.T) {
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			To:  {hs("+15555550100/TYPE=PLMN"), hs("192.0.2.1/TYPE=IPv4")},
+			Cc:  {hs("user@example.com/TYPE=RFC822")},
+			Bcc: {hs("other@example.com"), hs("12345")},
+		},
+	}
+
+	want := []Address{
+		{Value: "+15555550100", Type: PLMNAddress},
+		{Value: "192.0.2.1", Type: IPv4Address},
+		{Value: "user@example.com", Type: RFC822Address},
+		{Value: "other@example.com", Type: RFC822Address},
+		{Value: "12345"},
+	}
+	if diff := cmp.Diff(want, msg.Recipients()); diff != "" {
+		t.Errorf("recipients mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := msg.To(); len(got) != 2 || got[0] != "+15555550100/TYPE=PLMN" {
+		t.Errorf("raw To values changed: %q", got)
+	}
+
+	for _, s := range []string{"+15555550100/TYPE=PLMN", "192.0.2.1/TYPE=IPv4", "other@example.com", "12345"} {
+		if got := ParseAddress(s).String(); got != s {
+			t.Errorf("ParseAddress(%q).String() = %q", s, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package mms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalForm returns a normalized, human readable serialization of
+// the significant content of the message, suitable as a dedup key that
+// is stable across MMSC re-encodings. It covers the addresses, subject,
+// text bodies (decoded from their charset) and a SHA-256 of each other
+// attachment. Transfer encodings are removed from both first. Header
+// order, part order, address type suffixes, letter case of addresses
+// and line endings do not affect the result. SMIL presentation parts
+// are omitted since MMSCs routinely rewrite them.
+func (m *Message) CanonicalForm() string {
+	var b strings.Builder
+
+	addrs := func(f MMSField) []string {
+		var out []string
+		for _, v := range m.Header[f] {
+			out = append(out, canonicalAddress(v.String()))
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	writeLine := func(key, val string) {
+		b.WriteString(key)
+		b.WriteString(":")
+		b.WriteString(strconv.Quote(val))
+		b.WriteString("\n")
+	}
+
+	for _, a := range addrs(From) {
+		writeLine("from", a)
+	}
+	for _, f := range []MMSField{To, Cc, Bcc} {
+		for _, a := range addrs(f) {
+			writeLine(strings.ToLower(f.String()), a)
+		}
+	}
+	if subject := strings.TrimSpace(m.stringField(Subject)); subject != "" {
+		writeLine("subject", subject)
+	}
+
+	var texts, attachments []string
+	for i := range m.Parts {
+		part := &m.Parts[i]
+		if part.IsPresentation() {
+			continue
+		}
+		ct := mediaType(part.ContentType)
+		if strings.HasPrefix(ct, "text/") {
+			txt, err := part.Text()
+			if err == nil {
+				txt = strings.ReplaceAll(txt, "\r\n", "\n")
+				texts = append(texts, strings.TrimSpace(txt))
+				continue
+			}
+		}
+		data, err := part.DecodedData()
+		if err != nil {
+			data = part.Data
+		}
+		sum := sha256.Sum256(data)
+		attachments = append(attachments, ct+" sha256:"+hex.EncodeToString(sum[:]))
+	}
+	sort.Strings(texts)
+	sort.Strings(attachments)
+
+	for _, txt := range texts {
+		writeLine("text", txt)
+	}
+	for _, a := range attachments {
+		writeLine("attachment", a)
+	}
+
+	return b.String()
+}
+
+// canonicalAddress lowercases an address and strips any /TYPE= suffix.
+func canonicalAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if idx := strings.LastIndex(strings.ToUpper(addr), "/TYPE="); idx >= 0 {
+		addr = addr[:idx]
+	}
+	return strings.ToLower(addr)
+}
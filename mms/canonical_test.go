@@ -0,0 +1,72 @@
+package mms
+
+import "testing"
+
+func TestCanonicalForm(t *testing.T) {
+	a := Message{
+		Header: map[MMSField][]HeaderField{
+			From:    {hs("+15551231234/TYPE=PLMN")},
+			To:      {hs("Bob@Example.com/TYPE=RFC822"), hs("+15550001111/TYPE=PLMN")},
+			Subject: {hs(" hi ")},
+		},
+		Parts: []PDUPart{
+			{ContentType: "application/smil", Data: []byte("<smil/>")},
+			{ContentType: "text/plain", Header: map[string]string{"Character-Set": "UTF-8"}, Data: []byte("line1\r\nline2")},
+			{ContentType: "image/jpeg", Data: []byte{0xff, 0xd8}},
+		},
+	}
+
+	b := Message{
+		Header: map[MMSField][]HeaderField{
+			To:      {hs("+15550001111"), hs("bob@example.com")},
+			Subject: {hs("hi")},
+			From:    {hs("+15551231234")},
+		},
+		Parts: []PDUPart{
+			{ContentType: "IMAGE/JPEG", Header: map[string]string{"Content-Transfer-Encoding": "base64"}, Data: []byte("/9g=")},
+			{ContentType: "text/plain", Header: map[string]string{"Character-Set": "UTF-16"}, Data: []byte{0x00, 'l', 0x00, 'i', 0x00, 'n', 0x00, 'e', 0x00, '1', 0x00, '\n', 0x00, 'l', 0x00, 'i', 0x00, 'n', 0x00, 'e', 0x00, '2'}},
+			{ContentType: "application/smil", Data: []byte("<smil></smil>")},
+		},
+	}
+
+	expect := `from:"+15551231234"
+to:"+15550001111"
+to:"bob@example.com"
+subject:"hi"
+text:"line1\nline2"
+attachment:"image/jpeg sha256:71563ad80061407ede9c6f316836284bd3710a520c5a792b5eda1cb703690815"
+`
+
+	got := a.CanonicalForm()
+	if got != b.CanonicalForm() {
+		t.Fatalf("canonical forms differ:\n%s\n%s", got, b.CanonicalForm())
+	}
+	if got != expect {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, expect)
+	}
+}
+
+func TestCanonicalFormTransferEncodedText(t *testing.T) {
+	eightBit := Message{
+		Parts: []PDUPart{
+			{
+				ContentType: "text/plain",
+				Header:      map[string]string{"Character-Set": "UTF-8", "Content-Transfer-Encoding": "8bit"},
+				Data:        []byte("café"),
+			},
+		},
+	}
+	b64 := Message{
+		Parts: []PDUPart{
+			{
+				ContentType: "text/plain",
+				Header:      map[string]string{"Character-Set": "UTF-8", "Content-Transfer-Encoding": "base64"},
+				Data:        []byte("Y2Fmw6k="),
+			},
+		},
+	}
+
+	if got, want := b64.CanonicalForm(), eightBit.CanonicalForm(); got != want {
+		t.Fatalf("base64 text got:\n%s\nwant:\n%s", got, want)
+	}
+}
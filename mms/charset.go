@@ -0,0 +1,147 @@
+package mms
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// charsets maps IANA MIBEnum values to the preferred MIME name of the
+// charset. Only charsets seen in MMS traffic are listed.
+var charsets = map[int]string{
+	3:    "US-ASCII",
+	4:    "ISO-8859-1",
+	5:    "ISO-8859-2",
+	6:    "ISO-8859-3",
+	7:    "ISO-8859-4",
+	8:    "ISO-8859-5",
+	9:    "ISO-8859-6",
+	10:   "ISO-8859-7",
+	11:   "ISO-8859-8",
+	12:   "ISO-8859-9",
+	17:   "Shift_JIS",
+	18:   "EUC-JP",
+	38:   "EUC-KR",
+	39:   "ISO-2022-JP",
+	106:  "UTF-8",
+	1000: "ISO-10646-UCS-2",
+	1013: "UTF-16BE",
+	1014: "UTF-16LE",
+	1015: "UTF-16",
+	2025: "GB2312",
+	2026: "Big5",
+	2252: "windows-1252",
+}
+
+// CharsetName returns the preferred MIME name of the charset with IANA
+// MIBEnum mib, and whether it is known. MIBEnum 0 is the WSP
+// Any-charset and is reported as "*".
+func CharsetName(mib int) (string, bool) {
+	if mib == 0 {
+		return "*", true
+	}
+	name, ok := charsets[mib]
+	return name, ok
+}
+
+// versionDefaultCharset returns the charset assumed for text parts
+// that don't declare one. MMS 1.3 clients commonly send undeclared
+// UTF-8, while earlier versions follow the RFC 2046 US-ASCII default.
+func versionDefaultCharset(hdr map[MMSField][]HeaderField) string {
+	vals := hdr[MMSVersion]
+	if len(vals) > 0 {
+		var major, minor int
+		v := vals[0].String()
+		if len(v) == 3 && v[1] == '.' {
+			major, minor = int(v[0]-'0'), int(v[2]-'0')
+		}
+		if major > 1 || (major == 1 && minor >= 3) {
+			return "UTF-8"
+		}
+	}
+	return "US-ASCII"
+}
+
+// decodeCharset converts data in the named charset to a UTF-8 string.
+// ASCII, UTF-8, Latin-1 and the UTF-16 forms are handled directly, others
+// through golang.org/x/text. Unsupported charsets are returned as the
+// raw bytes. Invalid UTF-8, whether declared or passed through raw, is
+// replaced with U+FFFD so the result is always valid.
+func decodeCharset(charset string, data []byte) string {
+	switch strings.ToUpper(charset) {
+	case "US-ASCII", "ASCII":
+		// Undeclared text is frequently UTF-8 even where the spec
+		// says US-ASCII; keep it when it is valid.
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		return strings.Map(func(r rune) rune {
+			if r > 0x7f {
+				return utf8.RuneError
+			}
+			return r
+		}, string(data))
+	case "UTF-8":
+		return strings.ToValidUTF8(string(data), "\uFFFD")
+	case "ISO-8859-1", "LATIN1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case "UTF-16", "UTF-16BE", "ISO-10646-UCS-2":
+		order := binary.ByteOrder(binary.BigEndian)
+		if strings.EqualFold(charset, "UTF-16") && len(data) >= 2 {
+			switch {
+			case data[0] == 0xfe && data[1] == 0xff:
+				data = data[2:]
+			case data[0] == 0xff && data[1] == 0xfe:
+				order = binary.LittleEndian
+				data = data[2:]
+			}
+		}
+		return decodeUTF16(data, order)
+	case "UTF-16LE":
+		return decodeUTF16(data, binary.LittleEndian)
+	}
+
+	if enc, err := ianaindex.IANA.Encoding(charset); err == nil && enc != nil {
+		if out, err := enc.NewDecoder().Bytes(data); err == nil {
+			return string(out)
+		}
+	}
+
+	return strings.ToValidUTF8(string(data), "\uFFFD")
+}
+
+// isCharsetName reports whether name is a charset known to the
+// charsets table or golang.org/x/text.
+func isCharsetName(name string) bool {
+	for _, n := range charsets {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	enc, err := ianaindex.IANA.Encoding(name)
+	return err == nil && enc != nil
+}
+
+// isUTF16 reports whether charset uses 16 bit code units.
+func isUTF16(charset string) bool {
+	switch strings.ToUpper(charset) {
+	case "UTF-16", "UTF-16BE", "UTF-16LE", "ISO-10646-UCS-2":
+		return true
+	}
+	return false
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
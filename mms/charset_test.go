@@ -0,0 +1,26 @@
+package mms
+
+import "testing"
+
+func TestCharsetName(t *testing.T) {
+	checks := []struct {
+		mib  int
+		name string
+		ok   bool
+	}{
+		{0, "*", true},
+		{3, "US-ASCII", true},
+		{4, "ISO-8859-1", true},
+		{106, "UTF-8", true},
+		{1015, "UTF-16", true},
+		{2026, "Big5", true},
+		{9999, "", false},
+	}
+
+	for _, check := range checks {
+		name, ok := CharsetName(check.mib)
+		if name != check.name || ok != check.ok {
+			t.Errorf("%d: got %q, %t want %q, %t", check.mib, name, ok, check.name, check.ok)
+		}
+	}
+}
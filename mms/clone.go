@@ -0,0 +1,152 @@
+package mms
+
+// Clone returns a deep copy of m. Header values, part headers and data,
+// and every other map and slice are copied, so the clone can be
+// modified without affecting m. Header values of types defined outside
+// this package can't be copied and are shared, as are the errors
+// wrapped by PartErrors.
+func (m *Message) Clone() *Message {
+	c := Message{
+		AppHeaders:        cloneStringMap(m.AppHeaders),
+		ContentTypeParams: cloneParams(m.ContentTypeParams),
+	}
+
+	if m.PartErrors != nil {
+		c.PartErrors = make([]*PartError, len(m.PartErrors))
+		for i, pe := range m.PartErrors {
+			cpe := *pe
+			c.PartErrors[i] = &cpe
+		}
+	}
+
+	if m.Header != nil {
+		c.Header = make(map[MMSField][]HeaderField, len(m.Header))
+		for f, vals := range m.Header {
+			cvals := make([]HeaderField, len(vals))
+			for i, v := range vals {
+				cvals[i] = cloneHeaderField(v)
+			}
+			c.Header[f] = cvals
+		}
+	}
+
+	if m.Parts != nil {
+		c.Parts = make([]PDUPart, len(m.Parts))
+		for i, p := range m.Parts {
+			c.Parts[i] = p
+			c.Parts[i].Header = cloneStringMap(p.Header)
+			c.Parts[i].Params = cloneParams(p.Params)
+			c.Parts[i].Data = cloneBytes(p.Data)
+		}
+	}
+
+	if m.UnknownHeaders != nil {
+		c.UnknownHeaders = make([]UnknownHeader, len(m.UnknownHeaders))
+		for i, h := range m.UnknownHeaders {
+			c.UnknownHeaders[i] = h
+			c.UnknownHeaders[i].Raw = cloneBytes(h.Raw)
+		}
+	}
+
+	if m.RawHeaders != nil {
+		c.RawHeaders = make(map[MMSField][]byte, len(m.RawHeaders))
+		for f, raw := range m.RawHeaders {
+			c.RawHeaders[f] = cloneBytes(raw)
+		}
+	}
+
+	if m.FieldOrder != nil {
+		c.FieldOrder = append([]MMSField{}, m.FieldOrder...)
+	}
+
+	return &c
+}
+
+// cloneHeaderField returns a copy of v that shares no memory with it.
+func cloneHeaderField(v HeaderField) HeaderField {
+	switch v := v.(type) {
+	case *HeaderString:
+		c := *v
+		return &c
+	case *HeaderFrom:
+		c := *v
+		return &c
+	case *HeaderUint:
+		c := *v
+		return &c
+	case *HeaderBool:
+		c := *v
+		return &c
+	case *HeaderTime:
+		c := *v
+		return &c
+	case *HeaderRelativeOrAbsoluteTime:
+		var c HeaderRelativeOrAbsoluteTime
+		if v.Relative != nil {
+			rel := *v.Relative
+			c.Relative = &rel
+		}
+		if v.Absolute != nil {
+			abs := *v.Absolute
+			c.Absolute = &abs
+		}
+		return &c
+	case *HeaderMessageType:
+		c := *v
+		return &c
+	case *HeaderPriority:
+		c := *v
+		return &c
+	case *HeaderResponseStatus:
+		c := *v
+		return &c
+	case *HederSenderVisibility:
+		c := *v
+		return &c
+	case *HeaderStatus:
+		c := *v
+		return &c
+	case *HeaderReadStatus:
+		c := *v
+		return &c
+	case *HeaderCancelStatus:
+		c := *v
+		return &c
+	case *HeaderRetrieveStatus:
+		c := *v
+		return &c
+	case *HeaderReplyCharging:
+		c := *v
+		return &c
+	}
+	return v
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneParams(m map[WellKnownParam]string) map[WellKnownParam]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[WellKnownParam]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,68 @@
+package mms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClone(t *testing.T) {
+	typ := MRetrieveConf
+	expiry := time.Hour
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {&typ},
+			Subject:     {hs("Hello")},
+			Expiry:      {&HeaderRelativeOrAbsoluteTime{Relative: &expiry}},
+			ContentType: {hs("application/vnd.wap.multipart.related")},
+		},
+		AppHeaders:        map[string]string{"X-A": "a"},
+		ContentTypeParams: map[WellKnownParam]string{StartParam: "<smil>"},
+		FieldOrder:        []MMSField{MessageType, Subject, Expiry, ContentType},
+		PartErrors:        []*PartError{{Index: 1, Err: ErrTruncated}},
+		Parts: []PDUPart{
+			{
+				Header:      map[string]string{"Content-ID": "smil"},
+				ContentType: "application/smil",
+				Data:        []byte("<smil/>"),
+				Params:      map[WellKnownParam]string{SizeParam: "7"},
+			},
+		},
+	}
+	wantHeader := headerStrings(msg)
+
+	c := msg.Clone()
+	if diff := cmp.Diff(wantHeader, headerStrings(c)); diff != "" {
+		t.Fatalf("clone header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.Parts, c.Parts); diff != "" {
+		t.Fatalf("clone parts mismatch (-want +got):\n%s", diff)
+	}
+
+	*c.Header[Subject][0].(*HeaderString) = "hello"
+	*c.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime).Relative = time.Minute
+	*c.Header[MessageType][0].(*HeaderMessageType) = MSendReq
+	c.Header[To] = []HeaderField{hs("b")}
+	c.AppHeaders["X-A"] = "changed"
+	c.ContentTypeParams[StartParam] = "<other>"
+	c.FieldOrder[0] = To
+	c.Parts[0].Data[0] = 'X'
+	c.Parts[0].Header["Content-ID"] = "other"
+	c.Parts[0].Params[SizeParam] = "8"
+	c.PartErrors[0].Index = 2
+
+	if diff := cmp.Diff(wantHeader, headerStrings(msg)); diff != "" {
+		t.Errorf("original header changed (-want +got):\n%s", diff)
+	}
+	if msg.AppHeaders["X-A"] != "a" || msg.ContentTypeParams[StartParam] != "<smil>" || msg.FieldOrder[0] != MessageType {
+		t.Errorf("original maps changed: %v %v %v", msg.AppHeaders, msg.ContentTypeParams, msg.FieldOrder)
+	}
+	p := msg.Parts[0]
+	if string(p.Data) != "<smil/>" || p.Header["Content-ID"] != "smil" || p.Params[SizeParam] != "7" {
+		t.Errorf("original part changed: %+v", p)
+	}
+	if msg.PartErrors[0].Index != 1 {
+		t.Errorf("original part error changed: %v", msg.PartErrors[0])
+	}
+}
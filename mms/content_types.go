@@ -1,5 +1,9 @@
 package mms
 
+import "strings"
+
+// contentTypes is the WSP Content Type Assignments table from the OMNA
+// registry, indexed by Well-known-media short code.
 var contentTypes = [...]string{
 	0:  "*/*",
 	1:  "text/*",
@@ -15,7 +19,7 @@ var contentTypes = [...]string{
 	11: "multipart/*",
 	12: "multipart/mixed",
 	13: "multipart/form-data",
-	14: "multipart/byterantes",
+	14: "multipart/byteranges",
 	15: "multipart/alternative",
 	16: "application/*",
 	17: "application/java-vm",
@@ -77,4 +81,43 @@ var contentTypes = [...]string{
 	73: "application/vnd.oma.drm.content",
 	74: "application/vnd.oma.drm.rights+xml",
 	75: "application/vnd.oma.drm.rights+wbxml",
+	76: "application/vnd.wv.csp+xml",
+	77: "application/vnd.wv.csp+wbxml",
+	78: "application/vnd.syncml.ds.notification",
+	79: "audio/*",
+	80: "video/*",
+	81: "application/vnd.oma.dd2+xml",
+	82: "application/mikey",
+	83: "application/vnd.oma.dcd",
+	84: "application/vnd.oma.dcdc",
+	85: "text/x-vMessage",
+	86: "application/vnd.omads-email+wbxml",
+	87: "text/x-vBookmark",
+	88: "application/vnd.syncml.dm.notification",
+	89: "application/octet-stream",
+	90: "application/json",
+}
+
+// ContentTypeForCode returns the content type assigned to the WSP
+// well-known media short code.
+func ContentTypeForCode(code int) (string, bool) {
+	if code < 0 || code >= len(contentTypes) {
+		return "", false
+	}
+	return contentTypes[code], true
+}
+
+// CodeForContentType returns the WSP well-known media short code
+// assigned to contentType, ignoring case. Types without an assignment,
+// such as application/smil, must be written as Extension-media text.
+func CodeForContentType(contentType string) (byte, bool) {
+	if contentType == "" {
+		return 0, false
+	}
+	for code, ct := range contentTypes {
+		if strings.EqualFold(ct, contentType) {
+			return byte(code), true
+		}
+	}
+	return 0, false
 }
@@ -0,0 +1,41 @@
+package mms
+
+import "testing"
+
+func TestContentTypeCodes(t *testing.T) {
+	for _, tc := range []struct {
+		code byte
+		ct   string
+	}{
+		{0x03, "text/plain"},
+		{0x0e, "multipart/byteranges"},
+		{0x1d, "image/gif"},
+		{0x1e, "image/jpeg"},
+		{0x20, "image/png"},
+		{0x33, "application/vnd.wap.multipart.related"},
+		{0x3e, "application/vnd.wap.mms-message"},
+		{0x4f, "audio/*"},
+		{0x59, "application/octet-stream"},
+	} {
+		ct, ok := ContentTypeForCode(int(tc.code))
+		if !ok || ct != tc.ct {
+			t.Errorf("ContentTypeForCode(0x%02x) = %q, %t want %q", tc.code, ct, ok, tc.ct)
+		}
+		code, ok := CodeForContentType(tc.ct)
+		if !ok || code != tc.code {
+			t.Errorf("CodeForContentType(%q) = 0x%02x, %t want 0x%02x", tc.ct, code, ok, tc.code)
+		}
+	}
+
+	if code, ok := CodeForContentType("Image/JPEG"); !ok || code != 0x1e {
+		t.Errorf("CodeForContentType is case sensitive, got 0x%02x %t", code, ok)
+	}
+
+	// SMIL has no WSP assignment and is always sent as text.
+	if code, ok := CodeForContentType("application/smil"); ok {
+		t.Errorf("CodeForContentType(application/smil) = 0x%02x, want no code", code)
+	}
+	if _, ok := ContentTypeForCode(128); ok {
+		t.Errorf("ContentTypeForCode(128) should not be assigned")
+	}
+}
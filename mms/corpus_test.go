@@ -0,0 +1,118 @@
+package mms
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadCorpus returns the contents of every MMS PDU under examples/,
+// keyed by file name. The test is skipped if there are none.
+func loadCorpus(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	paths, err := filepath.Glob("../examples/mms.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no example PDUs found")
+	}
+
+	corpus := make(map[string][]byte)
+	for _, p := range paths {
+		packet, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corpus[filepath.Base(p)] = packet
+	}
+	return corpus
+}
+
+func TestCorpus(t *testing.T) {
+	for name, packet := range loadCorpus(t) {
+		packet := packet
+		t.Run(name, func(t *testing.T) {
+			var msg *Message
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("panic: %v", r)
+					}
+				}()
+				var err error
+				msg, err = Unmarshal(packet)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}()
+
+			checkInvariants(t, msg)
+		})
+	}
+}
+
+func checkInvariants(t *testing.T, msg *Message) {
+	t.Helper()
+
+	typ, ok := msg.field(MessageType).(*HeaderMessageType)
+	if !ok {
+		t.Fatal("missing Message-Type")
+	}
+
+	if *typ == MNotificationInd {
+		if msg.stringField(ContentLocation) == "" {
+			t.Error("notification missing Content-Location")
+		}
+		if msg.field(MessageSize) == nil {
+			t.Error("notification missing Message-Size")
+		}
+	} else if size, ok := msg.field(MessageSize).(*HeaderUint); ok {
+		var total int
+		for _, part := range msg.Parts {
+			total += len(part.Data)
+		}
+		if uint64(total) > uint64(*size) {
+			t.Errorf("parts total %d bytes, exceeding declared Message-Size %d", total, *size)
+		}
+	}
+
+	for i, part := range msg.Parts {
+		if strings.TrimSpace(part.ContentType) == "" {
+			t.Errorf("part %d has no content type", i)
+		}
+	}
+}
+
+// FuzzUnmarshal checks that arbitrary input, such as a PDU corrupted in
+// transit, produces an error rather than a panic.
+func FuzzUnmarshal(f *testing.F) {
+	paths, err := filepath.Glob("../examples/mms.*")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, p := range paths {
+		packet, err := os.ReadFile(p)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(packet)
+	}
+	f.Add([]byte{0x8c, 0x84, 0x89, 0x01})
+	f.Add([]byte{0x8c, 0x84, 0x96, 0x03, 0xea})
+	f.Add([]byte{0x8c, 0x84, 0x84, 0xa3, 0x01, 0x05, 0x00, 0x83, 0xae, 0x02})
+
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		if msg, err := Unmarshal(packet); err == nil {
+			_ = msg.String()
+			for i := range msg.Parts {
+				msg.Parts[i].DecodedData()
+			}
+		}
+		UnmarshalLenient(packet)
+		NewDecoder(bytes.NewReader(packet)).Decode()
+	})
+}
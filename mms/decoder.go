@@ -0,0 +1,136 @@
+package mms
+
+import (
+	"bufio"
+	"io"
+)
+
+// A Decoder reads and decodes an MMS PDU from an input stream. The
+// PDU is decoded as it is read rather than buffered in full first.
+// MMS PDUs carry no overall length, so the PDU extends to the end of
+// the stream.
+type Decoder struct {
+	r     io.Reader
+	br    *bufio.Reader
+	count countingReader
+	opts  DecodeOptions
+
+	// packet is the input set by ResetBytes, read in place by sr.
+	packet []byte
+	sr     sliceReader
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: r,
+	}
+}
+
+// Reset discards the decoder's state and rebinds it to read from r,
+// allowing one Decoder to be reused across many PDUs. Configuration
+// such as DefaultCharset is kept, as is the decoder's read buffer.
+func (d *Decoder) Reset(r io.Reader) {
+	d.r = r
+	d.packet = nil
+}
+
+// ResetBytes discards the decoder's state and rebinds it to decode
+// packet, a complete PDU already in memory. Decoding packet this way
+// reads it in place rather than through the read buffer, and since its
+// length is known the lenient heuristics that depend on it apply, as
+// they do for UnmarshalWithOptions. Together with DecodeInto this lets
+// a Decoder, for instance one kept in a sync.Pool, decode many stored
+// PDUs with few allocations beyond the decoded values themselves.
+//
+// packet must not be modified until decoding finishes.
+func (d *Decoder) ResetBytes(packet []byte) {
+	d.r = nil
+	d.packet = packet
+	if d.packet == nil {
+		d.packet = []byte{}
+	}
+}
+
+// DefaultCharset forces the charset assumed for text parts that don't
+// declare one, instead of the default implied by the message's MMS
+// version.
+func (d *Decoder) DefaultCharset(charset string) {
+	d.opts.DefaultCharset = charset
+}
+
+// Lenient enables or disables lenient decoding, as described by
+// DecodeOptions.Lenient.
+func (d *Decoder) Lenient(lenient bool) {
+	d.opts.Lenient = lenient
+}
+
+// MaxLength sets the largest value or part body the decoder accepts,
+// as described by DecodeOptions.MaxLength.
+func (d *Decoder) MaxLength(n int) {
+	d.opts.MaxLength = n
+}
+
+// RawHeaders enables or disables recording the encoded bytes of each
+// header field, as described by DecodeOptions.RawHeaders. Input read
+// from an io.Reader isn't recorded, only input set by ResetBytes.
+func (d *Decoder) RawHeaders(keep bool) {
+	d.opts.RawHeaders = keep
+}
+
+// SkipBadParts enables or disables decoding past multipart entries
+// that fail, as described by DecodeOptions.SkipBadParts.
+func (d *Decoder) SkipBadParts(skip bool) {
+	d.opts.SkipBadParts = skip
+}
+
+// Decode reads the PDU from its input and returns the decoded Message.
+func (d *Decoder) Decode() (*Message, error) {
+	var msg Message
+	if err := d.DecodeInto(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DecodeInto reads the PDU from its input and decodes it into m,
+// reusing m's existing allocations instead of creating new ones.
+//
+// The Header, AppHeaders and RawHeaders maps of m are cleared and
+// refilled (an emptied AppHeaders or RawHeaders map is dropped), and
+// the Parts slice is truncated and refilled in place, including
+// clearing and reusing the Header map of each part that fits in its
+// capacity, as are the UnknownHeaders and FieldOrder slices. Callers
+// must therefore not retain m.Header, m.Parts, m.UnknownHeaders,
+// m.FieldOrder or any part's Header across calls, and must copy
+// anything they need before reusing m. Header values, part Data,
+// unknown header Raw bytes and RawHeaders values are freshly allocated
+// on every call and remain valid afterwards.
+//
+// On error m is left in an unspecified state.
+func (d *Decoder) DecodeInto(m *Message) error {
+	if d.packet != nil {
+		d.sr = sliceReader{b: d.packet}
+		dec := decoder{
+			r:    &d.sr,
+			sr:   &d.sr,
+			size: int64(len(d.packet)),
+		}
+		return decodeInto(m, &dec, d.opts)
+	}
+
+	d.count = countingReader{r: d.r}
+	if d.br == nil {
+		d.br = bufio.NewReader(&d.count)
+	} else {
+		d.br.Reset(&d.count)
+	}
+
+	dec := decoder{
+		r:     d.br,
+		br:    d.br,
+		count: &d.count,
+		size:  -1,
+	}
+	return decodeInto(m, &dec, d.opts)
+}
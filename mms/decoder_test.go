@@ -0,0 +1,227 @@
+package mms
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeInto(t *testing.T) {
+	first := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8b, 'a', 0x00, // Message-ID: a
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x02,
+		0x04, 0x01, 0x83, 0xc0, 'x', 0x00, 'a',
+		0x01, 0x01, 0x83, 'b',
+	}
+	second := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 0x00, // X-Mms-Transaction-Id: t
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x01, 0x01, 0x83, 'c',
+	}
+
+	var msg Message
+	if err := NewDecoder(bytes.NewReader(first)).DecodeInto(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 2 || msg.Parts[0].Header["Content-ID"] != "x" {
+		t.Fatalf("unexpected parts: %+v", msg.Parts)
+	}
+
+	hdr := msg.Header
+	partHdr := msg.Parts[0].Header
+	if err := NewDecoder(bytes.NewReader(second)).DecodeInto(&msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.ValueOf(hdr).Pointer() != reflect.ValueOf(msg.Header).Pointer() {
+		t.Fatal("header map was not reused")
+	}
+	for f := range msg.Header {
+		switch f {
+		case MessageType, TransactionID, ContentType:
+		default:
+			t.Errorf("stale %s header", f)
+		}
+	}
+	if reflect.ValueOf(partHdr).Pointer() != reflect.ValueOf(msg.Parts[0].Header).Pointer() {
+		t.Fatal("part header map was not reused")
+	}
+	if got := msg.Header[TransactionID][0].String(); got != "t" {
+		t.Fatalf("transaction id got %q", got)
+	}
+	if len(msg.Parts) != 1 || string(msg.Parts[0].Data) != "c" {
+		t.Fatalf("unexpected parts: %+v", msg.Parts)
+	}
+	if _, ok := msg.Parts[0].Header["Content-ID"]; ok {
+		t.Fatal("stale part header")
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader([]byte{0x8c, 0x84, 0x98, 'a', 0x00}))
+	dec.DefaultCharset("UTF-8")
+
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec.Reset(bytes.NewReader(benchPacket))
+	msg, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg.Header[TransactionID]; ok {
+		t.Fatal("stale header after reset")
+	}
+	if len(msg.Parts) != 3 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	if msg.Parts[0].DefaultCharset != "UTF-8" {
+		t.Fatalf("default charset not kept across reset: %q", msg.Parts[0].DefaultCharset)
+	}
+}
+
+var benchPacket = []byte{
+	0x8c, 0x84, // m-retrieve-conf
+	0x8d, 0x92, // 1.2
+	0x8b, 'm', 's', 'g', '-', '1', 0x00, // Message-ID
+	0x96, 0x07, 0xea, 'h', 'e', 'l', 'l', 'o', 0x00, // Subject
+	0x84, 0xa3, // application/vnd.wap.multipart.mixed
+	0x03,
+	0x04, 0x05, 0x83, 0xc0, 'a', 0x00, 'h', 'e', 'l', 'l', 'o',
+	0x04, 0x05, 0x83, 0xc0, 'b', 0x00, 'w', 'o', 'r', 'l', 'd',
+	0x04, 0x04, 0x9e, 0xc0, 'c', 0x00, 0xff, 0xd8, 0xff, 0xd9,
+}
+
+func BenchmarkDecoderNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := NewDecoder(bytes.NewReader(benchPacket)).Decode()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoderReuse(b *testing.B) {
+	b.ReportAllocs()
+	var (
+		r   bytes.Reader
+		msg Message
+		dec = NewDecoder(&r)
+	)
+	for i := 0; i < b.N; i++ {
+		r.Reset(benchPacket)
+		dec.Reset(&r)
+		if err := dec.DecodeInto(&msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoderResetBytes(b *testing.B) {
+	b.ReportAllocs()
+	var (
+		msg Message
+		dec = NewDecoder(nil)
+	)
+	for i := 0; i < b.N; i++ {
+		dec.ResetBytes(benchPacket)
+		if err := dec.DecodeInto(&msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecoderResetBytes(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader([]byte{0x8c, 0x84, 0x98, 'a', 0x00}))
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		dec.ResetBytes(benchPacket)
+		msg, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := msg.Header[MessageID][0].String(); got != "msg-1" {
+			t.Fatalf("message id got %q", got)
+		}
+		if len(msg.Parts) != 3 || string(msg.Parts[1].Data) != "world" {
+			t.Fatalf("unexpected parts: %+v", msg.Parts)
+		}
+	}
+
+	dec.ResetBytes(nil)
+	if _, err := dec.Decode(); err != ErrTruncated {
+		t.Fatalf("empty packet err: %v", err)
+	}
+
+	dec.Reset(bytes.NewReader([]byte{0x8c, 0x84, 0x98, 'a', 0x00}))
+	msg, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg.Header[MessageID]; ok {
+		t.Fatal("stale header after reset")
+	}
+}
+
+func TestDecoderStreaming(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x96, 'h', 'i', 0x00, // Subject: hi
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x01, 0x02, 0x83, 'o', 'k',
+	}
+
+	// a pipe only ever yields what has been written so far
+	pr, pw := io.Pipe()
+	go func() {
+		for _, b := range packet {
+			pw.Write([]byte{b})
+		}
+		pw.Close()
+	}()
+
+	msg, err := NewDecoder(iotest.OneByteReader(pr)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject() != "hi" || len(msg.Parts) != 1 || string(msg.Parts[0].Data) != "ok" {
+		t.Errorf("unexpected message %s", msg)
+	}
+
+	// error positions are tracked without seeking
+	_, err = NewDecoder(iotest.OneByteReader(bytes.NewReader([]byte{0x8c, 0x84, 0x86, 0x01}))).Decode()
+	if err == nil || !strings.Contains(err.Error(), "pos:3,") {
+		t.Errorf("got error %v, want pos:3", err)
+	}
+}
+
+func TestDecoderMatchesUnmarshal(t *testing.T) {
+	// A data length of 128 with only 50 bytes of data.
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x01, 0x81, 0x00, 0x83,
+	}
+	packet = append(packet, bytes.Repeat([]byte{'a'}, 50)...)
+
+	if _, err := Unmarshal(packet); err == nil {
+		t.Errorf("Unmarshal accepted a truncated part")
+	}
+	if _, err := NewDecoder(bytes.NewReader(packet)).Decode(); err == nil {
+		t.Errorf("Decoder accepted a truncated part")
+	}
+}
@@ -0,0 +1,397 @@
+package mms
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encoder writes WSP encoded values. It is the inverse of decoder.
+type encoder struct {
+	w *bytes.Buffer
+}
+
+func newEncoder() *encoder {
+	return &encoder{
+		w: new(bytes.Buffer),
+	}
+}
+
+// encodeVarUint writes a Uintvar-integer, 7 bits per octet with the
+// most significant group first.
+func (e *encoder) encodeVarUint(v uint32) {
+	var tmp [5]byte
+	i := len(tmp) - 1
+	tmp[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		tmp[i] = byte(v&0x7f) | 0x80
+	}
+	e.w.Write(tmp[i:])
+}
+
+// encodeValueLength writes a Value-length, using the Short-length form
+// for lengths up to 30 and Length-quote (31) followed by a
+// Uintvar-integer otherwise.
+//
+//	Value-length = Short-length | (Length-quote Length)
+func (e *encoder) encodeValueLength(l uint32) {
+	if l <= 30 {
+		e.w.WriteByte(byte(l))
+		return
+	}
+	e.w.WriteByte(31)
+	e.encodeVarUint(l)
+}
+
+// encodeShortInt writes a Short-integer. v must be below 128.
+func (e *encoder) encodeShortInt(v byte) {
+	e.w.WriteByte(v | 0x80)
+}
+
+// encodeLongInt writes a Long-integer using the fewest octets.
+//
+//	Long-integer = Short-length Multi-octet-integer
+func (e *encoder) encodeLongInt(v uint64) {
+	var tmp [8]byte
+	i := len(tmp)
+	for {
+		i--
+		tmp[i] = byte(v)
+		v >>= 8
+		if v == 0 {
+			break
+		}
+	}
+	e.w.WriteByte(byte(len(tmp) - i))
+	e.w.Write(tmp[i:])
+}
+
+// encodeIntegerValue writes a Short-integer when v fits, otherwise a
+// Long-integer.
+//
+//	Integer-Value = Short-integer | Long-integer
+func (e *encoder) encodeIntegerValue(v uint64) {
+	if v < 128 {
+		e.encodeShortInt(byte(v))
+		return
+	}
+	e.encodeLongInt(v)
+}
+
+// encodeTextString writes a Text-string, quoting it if its first octet
+// is 128 or above.
+//
+//	Text-string = [Quote] *TEXT End-of-string
+func (e *encoder) encodeTextString(s string) {
+	if len(s) > 0 && s[0] > 127 {
+		e.w.WriteByte(127)
+	}
+	e.w.WriteString(s)
+	e.w.WriteByte(0)
+}
+
+// encodeEncodedString writes s as a UTF-8 Encoded-string-value.
+//
+//	Encoded-string-value = Text-string | Value-length Char-set Text-string
+func (e *encoder) encodeEncodedString(s string) {
+	e.encodeWithLength(func(sub *encoder) error {
+		sub.encodeIntegerValue(106) // UTF-8
+		sub.encodeTextString(s)
+		return nil
+	})
+}
+
+// encodeWithLength writes the value produced by fn preceded by its
+// Value-length.
+func (e *encoder) encodeWithLength(fn func(sub *encoder) error) error {
+	sub := newEncoder()
+	if err := fn(sub); err != nil {
+		return err
+	}
+	e.encodeValueLength(uint32(sub.w.Len()))
+	e.w.Write(sub.w.Bytes())
+	return nil
+}
+
+// encodeFrom writes a From-value.
+//
+//	From-value = Value-length (Address-present-token Encoded-string-value | Insert-address-token)
+func (e *encoder) encodeFrom(from HeaderFrom) {
+	e.encodeWithLength(func(sub *encoder) error {
+		if from.Insert {
+			sub.w.WriteByte(129)
+			return nil
+		}
+		sub.w.WriteByte(128)
+		sub.encodeEncodedString(from.Address)
+		return nil
+	})
+}
+
+// encodeBoolean writes Yes (128) or No (129).
+func (e *encoder) encodeBoolean(v bool) {
+	if v {
+		e.w.WriteByte(128)
+	} else {
+		e.w.WriteByte(129)
+	}
+}
+
+// encodeDate writes a Date-value as seconds since the Unix epoch.
+// Times before the epoch can't be represented.
+func (e *encoder) encodeDate(t time.Time) error {
+	secs := t.Unix()
+	if secs < 0 {
+		return fmt.Errorf("%w: date %s is before the unix epoch", ErrInvalidValue, t.UTC().Format(time.RFC3339))
+	}
+	e.encodeLongInt(uint64(secs))
+	return nil
+}
+
+// encodeRelativeOrAbsoluteTime writes a Delivery-time-value or
+// Expiry-value.
+//
+//	Delivery-time-value = Value-length (Absolute-token Date-value | Relative-token Delta-seconds-value)
+func (e *encoder) encodeRelativeOrAbsoluteTime(v *HeaderRelativeOrAbsoluteTime) error {
+	return e.encodeWithLength(func(sub *encoder) error {
+		switch {
+		case v.Absolute != nil:
+			sub.w.WriteByte(128)
+			if err := sub.encodeDate(*v.Absolute); err != nil {
+				return err
+			}
+		case v.Relative != nil:
+			sub.w.WriteByte(129)
+			sub.encodeLongInt(uint64(*v.Relative / time.Second))
+		default:
+			return fmt.Errorf("empty relative or absolute time")
+		}
+		return nil
+	})
+}
+
+// encodeMessageClass writes a Message-class-value, using the
+// Class-identifier for the classes the decoder names.
+func (e *encoder) encodeMessageClass(cls string) {
+	switch cls {
+	case "personal":
+		e.w.WriteByte(128)
+	case "advertisement":
+		e.w.WriteByte(129)
+	case "informational":
+		e.w.WriteByte(130)
+	case "auto":
+		e.w.WriteByte(131)
+	default:
+		e.encodeTextString(cls)
+	}
+}
+
+// encodeVersion writes an MMS-version-value such as "1.2".
+func (e *encoder) encodeVersion(version string) error {
+	majorStr, minorStr, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major < 1 || major > 7 {
+		return fmt.Errorf("invalid mms version %q", version)
+	}
+	minor := 15
+	if minorStr != "" {
+		minor, err = strconv.Atoi(minorStr)
+		if err != nil || minor < 0 || minor > 14 {
+			return fmt.Errorf("invalid mms version %q", version)
+		}
+	}
+	e.encodeShortInt(byte(major<<4 | minor))
+	return nil
+}
+
+// encodeMedia writes a Constrained-media value, using the
+// Well-known-media short integer when contentType has one.
+func (e *encoder) encodeMedia(contentType string) {
+	if code, ok := CodeForContentType(contentType); ok {
+		e.encodeShortInt(code)
+		return
+	}
+	e.encodeTextString(contentType)
+}
+
+// encodeContentTypeValue writes a Content-type-value for contentType
+// with the parameters held in the part header hdr and in params, using
+// the general form only when there are parameters to carry.
+//
+//	Content-type-value = Constrained-media | Content-general-form
+//	Content-general-form = Value-length Media-type
+//	Media-type = (Well-known-media | Extension-Media) *(Parameter)
+func (e *encoder) encodeContentTypeValue(contentType string, hdr map[string]string, params map[WellKnownParam]string) error {
+	pe := newEncoder()
+	if err := pe.encodeContentTypeParams(hdr); err != nil {
+		return err
+	}
+	if err := pe.encodeParams(params); err != nil {
+		return err
+	}
+	if pe.w.Len() == 0 {
+		e.encodeMedia(contentType)
+		return nil
+	}
+	return e.encodeWithLength(func(sub *encoder) error {
+		sub.encodeMedia(contentType)
+		sub.w.Write(pe.w.Bytes())
+		return nil
+	})
+}
+
+// encodeContentTypeParams writes the content type parameters stored in
+// a part header by setContentTypeParams.
+func (e *encoder) encodeContentTypeParams(hdr map[string]string) error {
+	if v, ok := hdr["Q"]; ok {
+		q, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid q value %q", v)
+		}
+		e.w.WriteByte(byte(QParam))
+		if err := e.encodeQValue(q); err != nil {
+			return err
+		}
+	}
+	if v, ok := hdr["Character-Set"]; ok {
+		e.w.WriteByte(byte(CharsetParam))
+		e.encodeCharset(v)
+	}
+	if v, ok := hdr["Type"]; ok {
+		e.w.WriteByte(byte(TypeParam))
+		e.encodeMedia(v)
+	}
+	for _, p := range []struct {
+		key   string
+		param WellKnownParam
+	}{
+		{"Creation-Date", CreationDateParam},
+		{"Modification-Date", ModificationDateParam},
+		{"Read-Date", ReadDateParam},
+	} {
+		v, ok := hdr[p.key]
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", p.key, v)
+		}
+		e.w.WriteByte(byte(p.param))
+		if err := e.encodeDate(t); err != nil {
+			return err
+		}
+	}
+	if v, ok := hdr["Name"]; ok {
+		e.w.WriteByte(byte(NameParam))
+		e.encodeTextString(v)
+	}
+	if v, ok := hdr["Start"]; ok {
+		e.w.WriteByte(byte(StartParam))
+		e.encodeTextString(v)
+	}
+
+	// Untyped-parameter = Token-text Untyped-value
+	var untyped []string
+	for k := range hdr {
+		if strings.HasPrefix(k, contentTypeParamPrefix) {
+			untyped = append(untyped, k)
+		}
+	}
+	sort.Strings(untyped)
+	for _, k := range untyped {
+		e.encodeTextString(strings.TrimPrefix(k, contentTypeParamPrefix))
+		e.encodeTextString(hdr[k])
+	}
+	return nil
+}
+
+// encodeParams writes the parameters recorded in PDUPart.Params, in
+// parameter code order. Parameters that are written from the part
+// header are skipped.
+func (e *encoder) encodeParams(params map[WellKnownParam]string) error {
+	keys := make([]WellKnownParam, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, k := range keys {
+		v := params[k]
+		switch k {
+		case QParam, CharsetParam, TypeParam, CtMrTypeParam, NameParam, DepNameParam,
+			StartParam, DepStartParam, CreationDateParam, ModificationDateParam, ReadDateParam:
+			continue
+		}
+
+		e.w.WriteByte(byte(k))
+		switch k {
+		case LevelParam:
+			if err := e.encodeVersion(v); err != nil {
+				e.encodeTextString(v)
+			}
+		case DifferencesParam:
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < 128 {
+				e.encodeShortInt(byte(n))
+			} else {
+				e.encodeTextString(v)
+			}
+		case PaddingParam, SecParam:
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > 127 {
+				return fmt.Errorf("invalid parameter 0x%x value %q", int(k), v)
+			}
+			e.encodeShortInt(byte(n))
+		case MaxAgeParam, SizeParam:
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid parameter 0x%x value %q", int(k), v)
+			}
+			e.encodeIntegerValue(n)
+		case SecureParam:
+			e.w.WriteByte(0)
+		default:
+			e.encodeTextString(v)
+		}
+	}
+	return nil
+}
+
+// encodeCharset writes a Well-known-charset for a charset name, or the
+// name as text if it has no known MIBEnum.
+func (e *encoder) encodeCharset(name string) {
+	if name == "*" {
+		e.w.WriteByte(128) // Any-charset
+		return
+	}
+	if mib, err := strconv.Atoi(name); err == nil && mib >= 0 {
+		e.encodeIntegerValue(uint64(mib))
+		return
+	}
+	for mib, n := range charsets {
+		if strings.EqualFold(n, name) {
+			e.encodeIntegerValue(uint64(mib))
+			return
+		}
+	}
+	e.encodeTextString(name)
+}
+
+// encodeQValue writes a Q-value, the inverse of decodeQValue.
+func (e *encoder) encodeQValue(q float64) error {
+	if q < 0 || q >= 1 {
+		return fmt.Errorf("q value %v out of range", q)
+	}
+	if hundredths := math.Round(q * 100); math.Abs(hundredths-q*100) < 1e-9 {
+		e.encodeVarUint(uint32(hundredths) + 1)
+		return nil
+	}
+	e.encodeVarUint(uint32(math.Round(q*1000)) + 100)
+	return nil
+}
@@ -0,0 +1,96 @@
+package mms
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncodeVarUint(t *testing.T) {
+	for _, v := range []uint32{0, 1, 127, 128, 16383, 16384, 1<<28 - 1, 1 << 28, 1<<32 - 1} {
+		e := newEncoder()
+		e.encodeVarUint(v)
+		got, err := newBytesDecoder(e.w.Bytes()).decodeVarUint()
+		if err != nil {
+			t.Errorf("%d: decode %x err: %s", v, e.w.Bytes(), err)
+			continue
+		}
+		if got != v {
+			t.Errorf("%d: got %d from %x", v, got, e.w.Bytes())
+		}
+	}
+}
+
+func TestEncodeValueLength(t *testing.T) {
+	for _, v := range []uint32{0, 1, 30, 31, 127, 128, 100000} {
+		e := newEncoder()
+		e.encodeValueLength(v)
+		if v <= 30 && e.w.Len() != 1 {
+			t.Errorf("%d: want short-length form, got %x", v, e.w.Bytes())
+		}
+		if v > 30 && e.w.Bytes()[0] != 31 {
+			t.Errorf("%d: want length-quote form, got %x", v, e.w.Bytes())
+		}
+		got, err := newBytesDecoder(e.w.Bytes()).decodeValueLength()
+		if err != nil {
+			t.Errorf("%d: decode %x err: %s", v, e.w.Bytes(), err)
+			continue
+		}
+		if got != v {
+			t.Errorf("%d: got %d from %x", v, got, e.w.Bytes())
+		}
+	}
+}
+
+func TestEncodeShortInt(t *testing.T) {
+	for _, v := range []byte{0, 1, 0x0c, 127} {
+		e := newEncoder()
+		e.encodeShortInt(v)
+		got, err := newBytesDecoder(e.w.Bytes()).decodeShortInt()
+		if err != nil {
+			t.Errorf("%d: decode %x err: %s", v, e.w.Bytes(), err)
+			continue
+		}
+		if got != v {
+			t.Errorf("%d: got %d from %x", v, got, e.w.Bytes())
+		}
+	}
+}
+
+func TestEncodeLongInt(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 1700000000, 1<<32 - 1} {
+		e := newEncoder()
+		e.encodeLongInt(v)
+		got, err := newBytesDecoder(e.w.Bytes()).decodeLongInt()
+		if err != nil {
+			t.Errorf("%d: decode %x err: %s", v, e.w.Bytes(), err)
+			continue
+		}
+		if uint64(got) != v {
+			t.Errorf("%d: got %d from %x", v, got, e.w.Bytes())
+		}
+	}
+}
+
+func TestEncodeDateBeforeEpoch(t *testing.T) {
+	e := newEncoder()
+	err := e.encodeDate(time.Unix(-1, 0))
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("got err %v want %v", err, ErrInvalidValue)
+	}
+
+	typ := MSendReq
+	date := HeaderTime(time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC))
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-1")},
+			MMSVersion:    {hs("1.2")},
+			Date:          {&date},
+			ContentType:   {hs("text/plain")},
+		},
+	}
+	if _, err := Marshal(msg); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("marshal got err %v want %v", err, ErrInvalidValue)
+	}
+}
@@ -0,0 +1,93 @@
+package mms_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/psanford/gsm/mms"
+)
+
+func ExampleUnmarshal() {
+	packet := []byte{
+		0x8c, 0x84, // X-Mms-Message-Type: m-retrieve-conf
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x96, 0x07, 0xea, 'h', 'e', 'l', 'l', 'o', 0x00, // Subject: hello (utf-8)
+		0x84, 0xa3, // Content-Type: application/vnd.wap.multipart.mixed
+		0x01,       // 1 part
+		0x01, 0x02, // header len 1, data len 2
+		0x83, // text/plain
+		'h', 'i',
+	}
+
+	msg, err := mms.Unmarshal(packet)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, f := range []mms.MMSField{mms.MessageType, mms.MMSVersion, mms.Subject, mms.ContentType} {
+		fmt.Printf("%s: %s\n", f, msg.Header[f][0])
+	}
+	for _, part := range msg.Parts {
+		txt, _ := part.Text()
+		fmt.Printf("part %s: %s\n", part.ContentType, txt)
+	}
+	// Output:
+	// Message-Type: m-retrieve-conf
+	// MMS-Version: 1.2
+	// Subject: hello
+	// Content-Type: application/vnd.wap.multipart.mixed
+	// part text/plain: hi
+}
+
+func ExampleMessage_ToMIME() {
+	msg, err := mms.Unmarshal([]byte{
+		0x8c, 0x84, // X-Mms-Message-Type: m-retrieve-conf
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x96, 'h', 'e', 'l', 'l', 'o', 0x00, // Subject: hello
+		0x84, 0xa3, // Content-Type: application/vnd.wap.multipart.mixed
+		0x01,       // 1 part
+		0x01, 0x02, // header len 1, data len 2
+		0x83, // text/plain
+		'h', 'i',
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	eml, err := msg.ToMIME()
+	if err != nil {
+		panic(err)
+	}
+
+	// The multipart boundary is random, so skip the Content-Type line.
+	header, _, _ := strings.Cut(string(eml), "\r\n\r\n")
+	for _, line := range strings.Split(header, "\r\n") {
+		if !strings.HasPrefix(line, "Content-Type:") {
+			fmt.Println(line)
+		}
+	}
+
+	back, err := mms.FromMIME(eml)
+	if err != nil {
+		panic(err)
+	}
+	txt, _ := back.Parts[0].Text()
+	fmt.Printf("part %s: %s\n", back.Parts[0].ContentType, txt)
+	// Output:
+	// Mime-Version: 1.0
+	// Subject: hello
+	// X-Mms-Message-Type: m-retrieve-conf
+	// X-Mms-Mms-Version: 1.2
+	// part text/plain: hi
+}
+
+func ExampleMessage_Priority() {
+	msg, err := mms.Unmarshal([]byte{0x8c, 0x84, 0x8d, 0x92})
+	if err != nil {
+		panic(err)
+	}
+
+	p := msg.Priority()
+	fmt.Println(p.String(), msg.HasPriority())
+	// Output: medium false
+}
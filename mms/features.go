@@ -0,0 +1,79 @@
+package mms
+
+import (
+	"sort"
+	"strings"
+)
+
+// Features returns the notable optional MMS features the message
+// exercises, sorted by name. It is intended for building coverage
+// matrices across a corpus of PDUs. The reported names are:
+//
+//	adaptation-disallowed   X-Mms-Adaptation-Allowed: no
+//	applic-id               application addressing (X-Mms-Applic-ID etc.)
+//	delivery-report         X-Mms-Delivery-Report: yes
+//	delivery-time           deferred delivery via X-Mms-Delivery-Time
+//	drm-content             a part with an OMA DRM content type
+//	read-report             the message is itself a read report
+//	read-report-request     X-Mms-Read-Report: yes
+//	reply-charging          any of the X-Mms-Reply-Charging fields
+//	sender-hidden           X-Mms-Sender-Visibility: hide
+//	smil                    multipart/related with a SMIL presentation
+func (m *Message) Features() []string {
+	var features []string
+
+	if m.boolField(DeliveryReport) {
+		features = append(features, "delivery-report")
+	}
+	if m.boolField(ReadReply) {
+		features = append(features, "read-report-request")
+	}
+	if allowed, ok := m.AdaptationAllowed(); ok && !allowed {
+		features = append(features, "adaptation-disallowed")
+	}
+	if m.field(DeliveryTime) != nil {
+		features = append(features, "delivery-time")
+	}
+	if vis, ok := m.field(SenderVisibility).(*HederSenderVisibility); ok && *vis == Hide {
+		features = append(features, "sender-hidden")
+	}
+	if m.IsReadReport() {
+		features = append(features, "read-report")
+	}
+
+	for _, f := range []MMSField{ApplicID, ReplyApplicID, AuxApplicInfo} {
+		if m.field(f) != nil {
+			features = append(features, "applic-id")
+			break
+		}
+	}
+
+	for _, f := range []MMSField{ReplayCharging, ReplayChargingDeadline, ReplayChargingID, ReplayChargingSize} {
+		if m.field(f) != nil {
+			features = append(features, "reply-charging")
+			break
+		}
+	}
+
+	for _, part := range m.Parts {
+		if strings.HasPrefix(strings.ToLower(part.ContentType), "application/vnd.oma.drm.") {
+			features = append(features, "drm-content")
+			break
+		}
+	}
+
+	if mediaType(m.stringField(ContentType)) == "application/vnd.wap.multipart.related" {
+		if _, ok := m.PresentationPart(); ok {
+			features = append(features, "smil")
+		}
+	}
+
+	sort.Strings(features)
+	return features
+}
+
+// boolField reports whether f is present and decoded as true.
+func (m *Message) boolField(f MMSField) bool {
+	b, ok := m.field(f).(*HeaderBool)
+	return ok && bool(*b)
+}
@@ -0,0 +1,37 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFeatures(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8d, 0x93, // 1.3
+		0x86, 0x80, // X-Mms-Delivery-Report: yes
+		0x90, 0x81, // X-Mms-Read-Report: no
+		0x94, 0x80, // X-Mms-Sender-Visibility: hide
+		0xbc, 0x81, // X-Mms-Adaptation-Allowed: no
+		0x84, 0xb3, // application/vnd.wap.multipart.related
+		0x01,
+		0x01, 0x01, 0xc8, 'x', // application/vnd.oma.drm.message
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"adaptation-disallowed", "delivery-report", "drm-content", "sender-hidden"}
+	if got := msg.Features(); !cmp.Equal(got, expect) {
+		t.Fatal(cmp.Diff(got, expect))
+	}
+
+	msg.Parts = append(msg.Parts, PDUPart{ContentType: "application/smil"})
+	expect = append(expect, "smil")
+	if got := msg.Features(); !cmp.Equal(got, expect) {
+		t.Fatal(cmp.Diff(got, expect))
+	}
+}
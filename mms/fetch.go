@@ -0,0 +1,84 @@
+package mms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// MessageContentType is the media type of an MMS PDU sent over HTTP.
+const MessageContentType = "application/vnd.wap.mms-message"
+
+// ContentTypeError is returned by FetchMessage when the MMSC responds
+// with something other than an MMS PDU, such as an HTML error page.
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("response content type %q is not %s", e.ContentType, MessageContentType)
+}
+
+// FetchOptions configures how FetchMessageWithOptions requests a
+// message.
+type FetchOptions struct {
+	// WAPProfile, if set, is sent in the x-wap-profile header. MMSCs
+	// use this UAProf URL to adapt content to the device, so it should
+	// name the profile of the device being emulated.
+	WAPProfile string
+}
+
+// FetchMessage retrieves the message announced by an
+// m-notification-ind from its X-Mms-Content-Location with an HTTP GET
+// and decodes the m-retrieve-conf in the response. If client is nil
+// http.DefaultClient is used; on a phone it must be one that routes
+// through the MMS APN. ctx covers both the request and the decoding.
+//
+// A non-2xx response is an error, and a response that isn't labelled
+// application/vnd.wap.mms-message returns a *ContentTypeError. A body
+// longer than DefaultMaxLength returns an error wrapping ErrTooLarge.
+func FetchMessage(ctx context.Context, client *http.Client, contentLocation string) (*Message, error) {
+	return FetchMessageWithOptions(ctx, client, contentLocation, FetchOptions{})
+}
+
+// FetchMessageWithOptions fetches a message like FetchMessage,
+// configured by opts.
+func FetchMessageWithOptions(ctx context.Context, client *http.Client, contentLocation string, opts FetchOptions) (*Message, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, contentLocation, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MessageContentType)
+	if opts.WAPProfile != "" {
+		req.Header.Set("x-wap-profile", opts.WAPProfile)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", contentLocation, resp.Status)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(ct); err != nil || mediaType != MessageContentType {
+		return nil, &ContentTypeError{ContentType: ct}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxLength+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: read body err: %w", contentLocation, err)
+	}
+	if len(body) > DefaultMaxLength {
+		return nil, fmt.Errorf("fetch %s: body exceeds %d bytes: %w", contentLocation, DefaultMaxLength, ErrTooLarge)
+	}
+	return UnmarshalContext(ctx, body)
+}
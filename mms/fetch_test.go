@@ -0,0 +1,80 @@
+package mms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchMessage(t *testing.T) {
+	const testProfile = "http://example.com/uaprof.xml"
+
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 0x00, // X-Mms-Transaction-Id: t
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x84, 0x83, // text/plain
+		'h', 'i',
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != MessageContentType {
+			t.Errorf("Accept got %q", got)
+		}
+		want := ""
+		if r.URL.Query().Get("profile") != "" {
+			want = testProfile
+		}
+		if got := r.Header.Get("x-wap-profile"); got != want {
+			t.Errorf("x-wap-profile got %q want %q", got, want)
+		}
+		switch r.URL.Path {
+		case "/msg":
+			w.Header().Set("Content-Type", MessageContentType)
+			w.Write(packet)
+		case "/large":
+			w.Header().Set("Content-Type", MessageContentType)
+			w.Write(packet)
+			w.Write(make([]byte, DefaultMaxLength))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html>gone</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	msg, err := FetchMessage(ctx, srv.Client(), srv.URL+"/msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MessageType() != MRetrieveConf || string(msg.Parts[0].Data) != "hi" {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	msg, err = FetchMessageWithOptions(ctx, srv.Client(), srv.URL+"/msg?profile=1", FetchOptions{WAPProfile: testProfile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Parts[0].Data) != "hi" {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	_, err = FetchMessage(ctx, srv.Client(), srv.URL+"/html")
+	var ctErr *ContentTypeError
+	if !errors.As(err, &ctErr) || ctErr.ContentType != "text/html; charset=utf-8" {
+		t.Fatalf("expected ContentTypeError, got %v", err)
+	}
+
+	if _, err := FetchMessage(ctx, srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Fatal("expected error for 404")
+	}
+
+	if _, err := FetchMessage(ctx, srv.Client(), srv.URL+"/large"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("oversized body err = %v, want ErrTooLarge", err)
+	}
+}
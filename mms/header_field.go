@@ -1,6 +1,7 @@
 package mms
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -12,10 +13,43 @@ func (hs *HeaderString) String() string {
 	return string(*hs)
 }
 
-type HeaderUint uint32
+// MarshalText implements encoding.TextMarshaler using String.
+func (hs HeaderString) MarshalText() ([]byte, error) {
+	return []byte(hs.String()), nil
+}
+
+// InsertAddressToken is the String form of a From value carrying the
+// Insert-address-token.
+const InsertAddressToken = "<insert-address-token>"
+
+// HeaderFrom is the value of the From field. Insert is set, and Address
+// empty, when the sender leaves its address for the MMSC to insert.
+type HeaderFrom struct {
+	Address string
+	Insert  bool
+}
+
+func (hf *HeaderFrom) String() string {
+	if hf.Insert {
+		return InsertAddressToken
+	}
+	return hf.Address
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (hf HeaderFrom) MarshalText() ([]byte, error) {
+	return []byte(hf.String()), nil
+}
+
+type HeaderUint uint64
 
 func (hu *HeaderUint) String() string {
-	return strconv.Itoa(int(*hu))
+	return strconv.FormatUint(uint64(*hu), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (hu HeaderUint) MarshalText() ([]byte, error) {
+	return []byte(hu.String()), nil
 }
 
 type HeaderBool bool
@@ -24,12 +58,22 @@ func (hb *HeaderBool) String() string {
 	return strconv.FormatBool(bool(*hb))
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (hb HeaderBool) MarshalText() ([]byte, error) {
+	return []byte(hb.String()), nil
+}
+
 type HeaderTime time.Time
 
 func (hd *HeaderTime) String() string {
 	return time.Time(*hd).Format(time.RFC3339)
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (hd HeaderTime) MarshalText() ([]byte, error) {
+	return []byte(hd.String()), nil
+}
+
 type HeaderRelativeOrAbsoluteTime struct {
 	Relative *time.Duration
 	Absolute *time.Time
@@ -43,6 +87,27 @@ func (h *HeaderRelativeOrAbsoluteTime) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler using String. A value
+// with neither time set is an error.
+func (h HeaderRelativeOrAbsoluteTime) MarshalText() ([]byte, error) {
+	if h.Absolute == nil && h.Relative == nil {
+		return nil, errors.New("empty relative or absolute time")
+	}
+	return []byte(h.String()), nil
+}
+
+// ResolveAbsolute returns the absolute time h names, taking a relative
+// time from received. An empty value resolves to the zero time.
+func (h *HeaderRelativeOrAbsoluteTime) ResolveAbsolute(received time.Time) time.Time {
+	switch {
+	case h.Absolute != nil:
+		return *h.Absolute
+	case h.Relative != nil:
+		return received.Add(*h.Relative)
+	}
+	return time.Time{}
+}
+
 type HeaderMessageType int
 
 const (
@@ -54,6 +119,23 @@ const (
 	MRetrieveConf      HeaderMessageType = 132
 	MAcknowledgeInd    HeaderMessageType = 133
 	MDeliveryInd       HeaderMessageType = 134
+	MReadRecInd        HeaderMessageType = 135
+	MReadOrigInd       HeaderMessageType = 136
+	MForwardReq        HeaderMessageType = 137
+	MForwardConf       HeaderMessageType = 138
+	MMboxStoreReq      HeaderMessageType = 139
+	MMboxStoreConf     HeaderMessageType = 140
+	MMboxViewReq       HeaderMessageType = 141
+	MMboxViewConf      HeaderMessageType = 142
+	MMboxUploadReq     HeaderMessageType = 143
+	MMboxUploadConf    HeaderMessageType = 144
+	MMboxDeleteReq     HeaderMessageType = 145
+	MMboxDeleteConf    HeaderMessageType = 146
+	MMboxDescr         HeaderMessageType = 147
+	MDeleteReq         HeaderMessageType = 148
+	MDeleteConf        HeaderMessageType = 149
+	MCancelReq         HeaderMessageType = 150
+	MCancelConf        HeaderMessageType = 151
 )
 
 func (mt *HeaderMessageType) String() string {
@@ -72,11 +154,50 @@ func (mt *HeaderMessageType) String() string {
 		return "m-acknowledge-ind"
 	case MDeliveryInd:
 		return "m-delivery-ind"
+	case MReadRecInd:
+		return "m-read-rec-ind"
+	case MReadOrigInd:
+		return "m-read-orig-ind"
+	case MForwardReq:
+		return "m-forward-req"
+	case MForwardConf:
+		return "m-forward-conf"
+	case MMboxStoreReq:
+		return "m-mbox-store-req"
+	case MMboxStoreConf:
+		return "m-mbox-store-conf"
+	case MMboxViewReq:
+		return "m-mbox-view-req"
+	case MMboxViewConf:
+		return "m-mbox-view-conf"
+	case MMboxUploadReq:
+		return "m-mbox-upload-req"
+	case MMboxUploadConf:
+		return "m-mbox-upload-conf"
+	case MMboxDeleteReq:
+		return "m-mbox-delete-req"
+	case MMboxDeleteConf:
+		return "m-mbox-delete-conf"
+	case MMboxDescr:
+		return "m-mbox-descr"
+	case MDeleteReq:
+		return "m-delete-req"
+	case MDeleteConf:
+		return "m-delete-conf"
+	case MCancelReq:
+		return "m-cancel-req"
+	case MCancelConf:
+		return "m-cancel-conf"
 	default:
 		return "UnknownMessageType"
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (mt HeaderMessageType) MarshalText() ([]byte, error) {
+	return []byte(mt.String()), nil
+}
+
 type HeaderPriority int
 
 const (
@@ -98,6 +219,11 @@ func (p *HeaderPriority) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (p HeaderPriority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
 type HeaderResponseStatus int
 
 const (
@@ -137,6 +263,11 @@ func (rs *HeaderResponseStatus) String() string {
 	return "Error-unspecified"
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (rs HeaderResponseStatus) MarshalText() ([]byte, error) {
+	return []byte(rs.String()), nil
+}
+
 type HederSenderVisibility int
 
 const (
@@ -154,6 +285,11 @@ func (v *HederSenderVisibility) String() string {
 	return fmt.Sprintf("SenderVisibilityUnknown<%d>", v)
 }
 
+// MarshalText implements encoding.TextMarshaler using String.
+func (v HederSenderVisibility) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
 type HeaderStatus int
 
 const (
@@ -179,3 +315,120 @@ func (s *HeaderStatus) String() string {
 	}
 	return fmt.Sprintf("StatusUnknown<%d>", s)
 }
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (s HeaderStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type HeaderReadStatus int
+
+const (
+	ReadStatusRead                    HeaderReadStatus = 128
+	ReadStatusDeletedWithoutBeingRead HeaderReadStatus = 129
+)
+
+func (s *HeaderReadStatus) String() string {
+	switch *s {
+	case ReadStatusRead:
+		return "read"
+	case ReadStatusDeletedWithoutBeingRead:
+		return "deleted-without-being-read"
+	}
+	return fmt.Sprintf("ReadStatusUnknown<%d>", *s)
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (s HeaderReadStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type HeaderCancelStatus int
+
+const (
+	CancelRequestReceived  HeaderCancelStatus = 128
+	CancelRequestCorrupted HeaderCancelStatus = 129
+)
+
+func (s *HeaderCancelStatus) String() string {
+	switch *s {
+	case CancelRequestReceived:
+		return "cancel-request-successfully-received"
+	case CancelRequestCorrupted:
+		return "cancel-request-corrupted"
+	}
+	return fmt.Sprintf("CancelStatusUnknown<%d>", *s)
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (s HeaderCancelStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type HeaderRetrieveStatus int
+
+const (
+	RetrieveStatusOk                               HeaderRetrieveStatus = 128
+	RetrieveStatusErrorTransientFailure            HeaderRetrieveStatus = 192
+	RetrieveStatusErrorTransientMessageNotFound    HeaderRetrieveStatus = 193
+	RetrieveStatusErrorTransientNetworkProblem     HeaderRetrieveStatus = 194
+	RetrieveStatusErrorPermanentFailure            HeaderRetrieveStatus = 224
+	RetrieveStatusErrorPermanentServiceDenied      HeaderRetrieveStatus = 225
+	RetrieveStatusErrorPermanentMessageNotFound    HeaderRetrieveStatus = 226
+	RetrieveStatusErrorPermanentContentUnsupported HeaderRetrieveStatus = 227
+)
+
+func (s *HeaderRetrieveStatus) String() string {
+	switch *s {
+	case RetrieveStatusOk:
+		return "Ok"
+	case RetrieveStatusErrorTransientFailure:
+		return "Error-transient-failure"
+	case RetrieveStatusErrorTransientMessageNotFound:
+		return "Error-transient-message-not-found"
+	case RetrieveStatusErrorTransientNetworkProblem:
+		return "Error-transient-network-problem"
+	case RetrieveStatusErrorPermanentFailure:
+		return "Error-permanent-failure"
+	case RetrieveStatusErrorPermanentServiceDenied:
+		return "Error-permanent-service-denied"
+	case RetrieveStatusErrorPermanentMessageNotFound:
+		return "Error-permanent-message-not-found"
+	case RetrieveStatusErrorPermanentContentUnsupported:
+		return "Error-permanent-content-unsupported"
+	}
+	return fmt.Sprintf("RetrieveStatusUnknown<%d>", *s)
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (s HeaderRetrieveStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type HeaderReplyCharging int
+
+const (
+	ReplyChargingRequested         HeaderReplyCharging = 128
+	ReplyChargingRequestedTextOnly HeaderReplyCharging = 129
+	ReplyChargingAccepted          HeaderReplyCharging = 130
+	ReplyChargingAcceptedTextOnly  HeaderReplyCharging = 131
+)
+
+func (c *HeaderReplyCharging) String() string {
+	switch *c {
+	case ReplyChargingRequested:
+		return "requested"
+	case ReplyChargingRequestedTextOnly:
+		return "requested-text-only"
+	case ReplyChargingAccepted:
+		return "accepted"
+	case ReplyChargingAcceptedTextOnly:
+		return "accepted-text-only"
+	}
+	return fmt.Sprintf("ReplyChargingUnknown<%d>", *c)
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (c HeaderReplyCharging) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
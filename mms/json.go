@@ -0,0 +1,60 @@
+package mms
+
+import "encoding/json"
+
+type jsonMessage struct {
+	Header         map[string][]string `json:"header"`
+	AppHeaders     map[string]string   `json:"app_headers,omitempty"`
+	UnknownHeaders []jsonUnknownHeader `json:"unknown_headers,omitempty"`
+	Parts          []jsonPart          `json:"parts"`
+}
+
+type jsonUnknownHeader struct {
+	Field    string `json:"field"`
+	Raw      []byte `json:"raw"`
+	Encoding string `json:"encoding"`
+}
+
+type jsonPart struct {
+	ContentType string            `json:"content_type"`
+	FileName    string            `json:"filename,omitempty"`
+	Header      map[string]string `json:"header"`
+	Data        []byte            `json:"data"`
+}
+
+// MarshalJSON renders the message for logging and debugging. Each
+// header field is keyed by its name and lists the String form of its
+// values. Parts carry their content type, file name, header map and
+// base64 encoded data.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	out := jsonMessage{
+		Header:     make(map[string][]string),
+		AppHeaders: m.AppHeaders,
+		Parts:      make([]jsonPart, 0, len(m.Parts)),
+	}
+
+	for f, vals := range m.Header {
+		for _, v := range vals {
+			out.Header[f.String()] = append(out.Header[f.String()], v.String())
+		}
+	}
+
+	for _, h := range m.UnknownHeaders {
+		out.UnknownHeaders = append(out.UnknownHeaders, jsonUnknownHeader{
+			Field:    h.Field.String(),
+			Raw:      h.Raw,
+			Encoding: h.Encoding.String(),
+		})
+	}
+
+	for _, p := range m.Parts {
+		out.Parts = append(out.Parts, jsonPart{
+			ContentType: p.ContentType,
+			FileName:    p.FileName,
+			Header:      p.Header,
+			Data:        p.Data,
+		})
+	}
+
+	return json.Marshal(out)
+}
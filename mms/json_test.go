@@ -0,0 +1,89 @@
+package mms
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	typ := MRetrieveConf
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {&typ},
+			To:          {hs("a@example.com"), hs("b@example.com")},
+			ContentType: {hs("application/vnd.wap.multipart.mixed")},
+		},
+		Parts: []PDUPart{
+			{
+				Header:      map[string]string{"Content-ID": "<0>"},
+				FileName:    "hi.txt",
+				ContentType: "text/plain",
+				Data:        []byte("hi"),
+			},
+		},
+	}
+
+	out, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"header": map[string]interface{}{
+			"Message-Type": []interface{}{"m-retrieve-conf"},
+			"To":           []interface{}{"a@example.com", "b@example.com"},
+			"Content-Type": []interface{}{"application/vnd.wap.multipart.mixed"},
+		},
+		"parts": []interface{}{
+			map[string]interface{}{
+				"content_type": "text/plain",
+				"filename":     "hi.txt",
+				"header":       map[string]interface{}{"Content-ID": "<0>"},
+				"data":         "aGk=",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("json mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHeaderFieldMarshalText(t *testing.T) {
+	typ := MRetrieveConf
+	rel := 90 * time.Second
+	size := HeaderUint(1000)
+	fields := map[string]HeaderField{
+		"type":    &typ,
+		"expiry":  &HeaderRelativeOrAbsoluteTime{Relative: &rel},
+		"size":    &size,
+		"subject": hs("hi"),
+	}
+	got, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"expiry":"1m30s","size":"1000","subject":"hi","type":"m-retrieve-conf"}`
+	if string(got) != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+
+	got, err = json.Marshal(map[string]HeaderPriority{"p": High})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"p":"high"}`; string(got) != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+
+	if _, err := json.Marshal(&HeaderRelativeOrAbsoluteTime{}); err == nil {
+		t.Error("expected error for empty relative or absolute time")
+	}
+}
@@ -0,0 +1,384 @@
+package mms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Marshal encodes msg as a binary MMS PDU, the inverse of Unmarshal.
+//
+// Header fields are written in the order given by Fields, so a decoded
+// message keeps the field order it was received with. Fields not in
+// FieldOrder follow, with Message-Type, Transaction-ID and MMS-Version
+// first as WAP-209 requires and the rest in field code order. Any
+// application headers and unknown headers come next, and finally
+// Content-Type and the body. Encoded-string values are written as
+// UTF-8 with an explicit charset.
+//
+// A message with a multipart Content-Type has its parts written as
+// multipart entries, with ContentTypeParams on the Content-Type.
+// Otherwise the single part's parameters are written on the top level
+// Content-Type and its data is the body.
+func Marshal(msg *Message) ([]byte, error) {
+	e := newEncoder()
+
+	for _, fv := range msg.Fields() {
+		if fv.Field == ContentType {
+			continue
+		}
+		e.encodeShortInt(byte(fv.Field))
+		if err := e.encodeField(fv.Field, fv.Value); err != nil {
+			return nil, fmt.Errorf("encode %s err: %w", fv.Field, err)
+		}
+	}
+
+	names := make([]string, 0, len(msg.AppHeaders))
+	for name := range msg.AppHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e.encodeTextString(name)
+		e.encodeTextString(msg.AppHeaders[name])
+	}
+
+	for _, h := range msg.UnknownHeaders {
+		e.encodeShortInt(byte(h.Field))
+		e.w.Write(h.Raw)
+	}
+
+	ct := msg.Header[ContentType]
+	if len(ct) == 0 {
+		if len(msg.Parts) > 0 {
+			return nil, fmt.Errorf("message with parts has no Content-Type")
+		}
+		return e.w.Bytes(), nil
+	}
+	contentType := ct[0].String()
+
+	e.encodeShortInt(byte(ContentType))
+	if !isMultipart(contentType) {
+		if len(msg.Parts) > 1 {
+			return nil, fmt.Errorf("single part Content-Type %s with %d parts", contentType, len(msg.Parts))
+		}
+		var part PDUPart
+		if len(msg.Parts) == 1 {
+			part = msg.Parts[0]
+		}
+		if err := e.encodeContentTypeValue(contentType, part.Header, part.Params); err != nil {
+			return nil, fmt.Errorf("encode Content-Type err: %w", err)
+		}
+		e.w.Write(part.Data)
+		return e.w.Bytes(), nil
+	}
+
+	if err := e.encodeMessageContentType(contentType, msg.ContentTypeParams); err != nil {
+		return nil, fmt.Errorf("encode Content-Type err: %w", err)
+	}
+	if err := e.encodeBody(msg.Parts); err != nil {
+		return nil, err
+	}
+
+	return e.w.Bytes(), nil
+}
+
+// encodeMessageContentType writes the top level Content-Type of a
+// multipart message with its parameters.
+func (e *encoder) encodeMessageContentType(contentType string, params map[WellKnownParam]string) error {
+	p := PDUPart{Header: make(map[string]string)}
+	p.setContentTypeParams(params)
+	// setContentTypeParams files the Type parameter under the part's
+	// Content-Type key.
+	if t, ok := p.Header["Content-Type"]; ok {
+		p.Header["Type"] = t
+		delete(p.Header, "Content-Type")
+	}
+	return e.encodeContentTypeValue(contentType, p.Header, p.Params)
+}
+
+// headerOrder returns the fields of hdr in the order they are encoded:
+// Message-Type, Transaction-ID and MMS-Version first, then the rest by
+// field code, with Content-Type last.
+func headerOrder(hdr map[MMSField][]HeaderField) []MMSField {
+	fields := make([]MMSField, 0, len(hdr))
+	for f := range hdr {
+		switch f {
+		case MessageType, TransactionID, MMSVersion, ContentType:
+			continue
+		}
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i] < fields[j] })
+
+	out := make([]MMSField, 0, len(hdr))
+	for _, f := range []MMSField{MessageType, TransactionID, MMSVersion} {
+		if _, ok := hdr[f]; ok {
+			out = append(out, f)
+		}
+	}
+	out = append(out, fields...)
+	if _, ok := hdr[ContentType]; ok {
+		out = append(out, ContentType)
+	}
+	return out
+}
+
+// encodeField writes the value of a header field.
+func (e *encoder) encodeField(f MMSField, v HeaderField) error {
+	switch f {
+	case Bcc, Cc, ResponseText, RetrieveText, Subject, To:
+		e.encodeEncodedString(v.String())
+	case From:
+		e.encodeFrom(headerFrom(v))
+	case DeliveryReport, ReadReply, ReportAllowed, AdaptationAllowed:
+		hb, ok := v.(*HeaderBool)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.encodeBoolean(bool(*hb))
+	case Date:
+		ht, ok := v.(*HeaderTime)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		if err := e.encodeDate(time.Time(*ht)); err != nil {
+			return err
+		}
+	case DeliveryTime, Expiry, ReplayChargingDeadline:
+		rt, ok := v.(*HeaderRelativeOrAbsoluteTime)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		return e.encodeRelativeOrAbsoluteTime(rt)
+	case MessageSize, ReplayChargingSize:
+		hu, ok := v.(*HeaderUint)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.encodeLongInt(uint64(*hu))
+	case Start:
+		hu, ok := v.(*HeaderUint)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.encodeIntegerValue(uint64(*hu))
+	case MessageClass:
+		e.encodeMessageClass(v.String())
+	case MessageID, ContentLocation, TransactionID, ReplayChargingID, ApplicID, ReplyApplicID, AuxApplicInfo, CancelID:
+		e.encodeTextString(v.String())
+	case MessageType:
+		mt, ok := v.(*HeaderMessageType)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*mt))
+	case MMSVersion:
+		return e.encodeVersion(v.String())
+	case Priority:
+		p, ok := v.(*HeaderPriority)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*p))
+	case ResponseStatus:
+		rs, ok := v.(*HeaderResponseStatus)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*rs))
+	case SenderVisibility:
+		sv, ok := v.(*HederSenderVisibility)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*sv))
+	case StatusField:
+		s, ok := v.(*HeaderStatus)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*s))
+	case ReadStatus:
+		s, ok := v.(*HeaderReadStatus)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*s))
+	case ReplayCharging:
+		c, ok := v.(*HeaderReplyCharging)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*c))
+	case RetrieveStatus:
+		s, ok := v.(*HeaderRetrieveStatus)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*s))
+	case CancelStatus:
+		s, ok := v.(*HeaderCancelStatus)
+		if !ok {
+			return fmt.Errorf("invalid value type %T", v)
+		}
+		e.w.WriteByte(byte(*s))
+	default:
+		return fmt.Errorf("unsupported field")
+	}
+	return nil
+}
+
+// encodeBody writes the multipart entries of a message body.
+//
+//	Multipart = nEntries *MultipartEntry
+//	MultipartEntry = HeadersLen DataLen ContentType Headers Data
+func (e *encoder) encodeBody(parts []PDUPart) error {
+	e.encodeVarUint(uint32(len(parts)))
+
+	for i, part := range parts {
+		headers := newEncoder()
+		if err := headers.encodePartHeaders(&part); err != nil {
+			return fmt.Errorf("encode part %d headers err: %w", i, err)
+		}
+
+		e.encodeVarUint(uint32(headers.w.Len()))
+		e.encodeVarUint(uint32(len(part.Data)))
+		e.w.Write(headers.w.Bytes())
+		e.w.Write(part.Data)
+	}
+
+	return nil
+}
+
+// encodePartHeaders writes the content type and headers of a multipart
+// entry. Header keys that aren't content type parameters or well-known
+// part headers are written as application headers.
+func (e *encoder) encodePartHeaders(p *PDUPart) error {
+	if err := e.encodeContentTypeValue(p.ContentType, p.Header, p.Params); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(p.Header))
+	for k := range p.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dispParams := dispositionParams(p.Header)
+	wroteDisposition := false
+	for _, k := range keys {
+		v := p.Header[k]
+		if _, ok := dispositionParam(k); ok {
+			// written with the Content-Disposition
+			continue
+		}
+		if strings.HasPrefix(k, contentTypeParamPrefix) {
+			// written as an untyped content type parameter
+			continue
+		}
+		switch k {
+		case "Character-Set", "Name", "Start", "Type", "Q", "Creation-Date", "Modification-Date", "Read-Date":
+			// written as content type parameters
+		case ContentLocationPartHeader.String(), ContentIDPartHeader.String():
+			header := ContentLocationPartHeader
+			if k == ContentIDPartHeader.String() {
+				header = ContentIDPartHeader
+			}
+			e.w.WriteByte(byte(header))
+			e.encodeTextString(v)
+		case ContentTransferEncodingPartHeader.String():
+			e.w.WriteByte(byte(ContentTransferEncodingPartHeader))
+			e.encodeTextString(v)
+		case ContentDispositionPartHeader.String(), DepContentDispositionPartHeader.String():
+			header := ContentDispositionPartHeader
+			if k == DepContentDispositionPartHeader.String() {
+				header = DepContentDispositionPartHeader
+			}
+			e.w.WriteByte(byte(header))
+			if err := e.encodeDisposition(v, p.FileName, dispParams); err != nil {
+				return err
+			}
+			wroteDisposition = true
+		default:
+			e.encodeTextString(k)
+			e.encodeTextString(v)
+		}
+	}
+
+	if !wroteDisposition && (p.FileName != "" || len(dispParams) > 0) {
+		e.w.WriteByte(byte(ContentDispositionPartHeader))
+		return e.encodeDisposition(AttachmentDisposition.String(), p.FileName, dispParams)
+	}
+
+	return nil
+}
+
+// dispositionParam returns the Content-Disposition parameter a part
+// header key recorded by setDispositionParams names.
+func dispositionParam(key string) (WellKnownParam, bool) {
+	name, ok := strings.CutPrefix(key, dispositionParamPrefix)
+	if !ok {
+		return 0, false
+	}
+	return paramByName(name)
+}
+
+// dispositionParams returns the Content-Disposition parameters recorded
+// in a part header, or nil if there are none.
+func dispositionParams(hdr map[string]string) map[WellKnownParam]string {
+	var out map[WellKnownParam]string
+	for k, v := range hdr {
+		if param, ok := dispositionParam(k); ok {
+			if out == nil {
+				out = make(map[WellKnownParam]string)
+			}
+			out[param] = v
+		}
+	}
+	return out
+}
+
+// encodeDisposition writes a Content-disposition-value.
+//
+//	Content-disposition-value = Value-length Disposition *(Parameter)
+//	Disposition = Form-data | Attachment | Inline | Token-text
+func (e *encoder) encodeDisposition(disposition, fileName string, params map[WellKnownParam]string) error {
+	return e.encodeWithLength(func(sub *encoder) error {
+		switch disposition {
+		case FormDataDisposition.String():
+			sub.w.WriteByte(byte(FormDataDisposition))
+		case AttachmentDisposition.String():
+			sub.w.WriteByte(byte(AttachmentDisposition))
+		case InlineDisposition.String():
+			sub.w.WriteByte(byte(InlineDisposition))
+		default:
+			sub.encodeTextString(disposition)
+		}
+		if fileName != "" {
+			sub.w.WriteByte(byte(FilenameParam))
+			sub.encodeTextString(fileName)
+		}
+		// encodeParams leaves Name and the dates to the part header.
+		if name, ok := params[NameParam]; ok {
+			sub.w.WriteByte(byte(NameParam))
+			sub.encodeTextString(name)
+		}
+		for _, p := range []WellKnownParam{CreationDateParam, ModificationDateParam, ReadDateParam} {
+			v, ok := params[p]
+			if !ok {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s %q", dispositionParamPrefix, p, v)
+			}
+			sub.w.WriteByte(byte(p))
+			if err := sub.encodeDate(t); err != nil {
+				return err
+			}
+		}
+		return sub.encodeParams(params)
+	})
+}
@@ -0,0 +1,240 @@
+package mms
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// headerStrings flattens a message header to strings so messages can
+// be compared without reaching into time.Time internals.
+func headerStrings(m *Message) map[string][]string {
+	out := make(map[string][]string)
+	for f, vals := range m.Header {
+		for _, v := range vals {
+			out[f.String()] = append(out[f.String()], v.String())
+		}
+	}
+	return out
+}
+
+func checkRoundTrip(t *testing.T, msg *Message) {
+	t.Helper()
+
+	packet, err := Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatalf("unmarshal of %x err: %s", packet, err)
+	}
+
+	if diff := cmp.Diff(headerStrings(msg), headerStrings(got)); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.AppHeaders, got.AppHeaders); diff != "" {
+		t.Errorf("app header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.ContentTypeParams, got.ContentTypeParams); diff != "" {
+		t.Errorf("content type params mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.Parts, got.Parts); diff != "" {
+		t.Errorf("parts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	typ := MRetrieveConf
+	date := HeaderTime(time.Unix(1700000000, 0))
+	expiry := 3 * 24 * time.Hour
+	priority := High
+	size := HeaderUint(70000)
+	deliveryReport := HeaderBool(false)
+
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:    {&typ},
+			TransactionID:  {hs("tx-1")},
+			MMSVersion:     {hs("1.2")},
+			MessageID:      {hs("msg-1")},
+			Date:           {&date},
+			From:           {hs("+15555550100/TYPE=PLMN")},
+			To:             {hs("+15555550101/TYPE=PLMN"), hs("+15555550102/TYPE=PLMN")},
+			Subject:        {hs("a subject long enough to need the length quote")},
+			MessageClass:   {hs("personal")},
+			Priority:       {&priority},
+			MessageSize:    {&size},
+			DeliveryReport: {&deliveryReport},
+			Expiry:         {&HeaderRelativeOrAbsoluteTime{Relative: &expiry}},
+			ContentType:    {hs("application/vnd.wap.multipart.related")},
+		},
+		AppHeaders: map[string]string{
+			"X-Carrier": "example",
+		},
+		Parts: []PDUPart{
+			{
+				Header: map[string]string{
+					"Character-Set":       "UTF-8",
+					"Content-ID":          "\"<text0>",
+					"Content-Location":    "text0.txt",
+					"Content-Type-Format": "flowed",
+					"X-Part":              "extra",
+				},
+				ContentType:    "text/plain",
+				Data:           []byte("hello"),
+				DefaultCharset: "US-ASCII",
+			},
+			{
+				Header: map[string]string{
+					"Name":                "photo.jpg",
+					"Content-Disposition": "AttachmentDisposition",
+					"Creation-Date":       "2023-11-14T22:13:20Z",
+				},
+				FileName:       "photo.jpg",
+				ContentType:    "image/jpeg",
+				Data:           make([]byte, 300),
+				DefaultCharset: "US-ASCII",
+			},
+		},
+	}
+
+	checkRoundTrip(t, msg)
+}
+
+func TestMarshalSinglePart(t *testing.T) {
+	typ := MSendReq
+
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-2")},
+			MMSVersion:    {hs("1.3")},
+			From:          {hs("<insert-address-token>")},
+			ContentType:   {hs("text/plain")},
+		},
+		Parts: []PDUPart{
+			{
+				Header: map[string]string{
+					"Character-Set": "UTF-8",
+				},
+				ContentType:    "text/plain",
+				Data:           []byte("just text"),
+				DefaultCharset: "UTF-8",
+			},
+		},
+	}
+
+	checkRoundTrip(t, msg)
+}
+
+func TestMarshalNonASCIIPartIDs(t *testing.T) {
+	p := &PDUPart{
+		Header: map[string]string{
+			"Content-ID":       "\u00e9t\u00e9",
+			"Content-Location": "\u00e9t\u00e9.txt",
+		},
+		ContentType: "text/plain",
+		Data:        []byte("summer"),
+	}
+
+	e := newEncoder()
+	if err := e.encodePartHeaders(p); err != nil {
+		t.Fatal(err)
+	}
+	got := e.w.Bytes()
+	for _, want := range [][]byte{
+		append([]byte{byte(ContentIDPartHeader), 0x7f}, "\u00e9t\u00e9\x00"...),
+		append([]byte{byte(ContentLocationPartHeader), 0x7f}, "\u00e9t\u00e9.txt\x00"...),
+	} {
+		if !bytes.Contains(got, want) {
+			t.Errorf("headers %x missing quoted text %x", got, want)
+		}
+	}
+
+	typ := MSendReq
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-3")},
+			MMSVersion:    {hs("1.2")},
+			ContentType:   {hs("application/vnd.wap.multipart.mixed")},
+		},
+		Parts: []PDUPart{*p},
+	}
+	msg.Parts[0].DefaultCharset = "US-ASCII"
+	checkRoundTrip(t, msg)
+}
+
+func TestMarshalCorpus(t *testing.T) {
+	for name, packet := range loadCorpus(t) {
+		packet := packet
+		t.Run(name, func(t *testing.T) {
+			msg, err := Unmarshal(packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRoundTrip(t, msg)
+		})
+	}
+}
+
+func TestMarshalFieldOrder(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 0x00, // X-Mms-Transaction-Id: t
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x97, 0x03, 0xea, 'a', 0x00, // To: a
+		0x96, 0x03, 0xea, 's', 0x00, // Subject: s
+		0x82, 0x03, 0xea, 'c', 0x00, // Cc: c
+		0x97, 0x03, 0xea, 'b', 0x00, // To: b
+		0x89, 0x01, 0x81, // From: insert-address-token
+		0x84, 0x83, // text/plain
+		'h', 'i',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOrder := []MMSField{MessageType, TransactionID, MMSVersion, To, Subject, Cc, To, From, ContentType}
+	if diff := cmp.Diff(wantOrder, msg.FieldOrder); diff != "" {
+		t.Errorf("field order mismatch (-want +got):\n%s", diff)
+	}
+	var gotFields []string
+	for _, fv := range msg.Fields() {
+		gotFields = append(gotFields, fv.Field.String()+"="+fv.Value.String())
+	}
+	wantFields := []string{
+		"Message-Type=m-retrieve-conf", "Transaction-ID=t", "MMS-Version=1.2",
+		"To=a", "Subject=s", "Cc=c", "To=b", "From=<insert-address-token>",
+		"Content-Type=text/plain",
+	}
+	if diff := cmp.Diff(wantFields, gotFields); diff != "" {
+		t.Errorf("fields mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(packet, got); diff != "" {
+		t.Errorf("re-encoding mismatch (-want +got):\n%s", diff)
+	}
+
+	// Fields edited after decoding: removed ones are skipped and added
+	// ones follow those in FieldOrder.
+	delete(msg.Header, Subject)
+	msg.Header[To] = msg.Header[To][:1]
+	msg.Header[MessageID] = []HeaderField{hs("m")}
+	gotFields = gotFields[:0]
+	for _, fv := range msg.Fields() {
+		gotFields = append(gotFields, fv.Field.String())
+	}
+	wantFields = []string{"Message-Type", "Transaction-ID", "MMS-Version", "To", "Cc", "From", "Message-ID", "Content-Type"}
+	if diff := cmp.Diff(wantFields, gotFields); diff != "" {
+		t.Errorf("edited fields mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,42 @@
+package mms
+
+// MergeNotification returns a new Message combining an
+// m-notification-ind with the m-retrieve-conf fetched for it. Fields
+// present in notif but absent from retrieved, such as Expiry,
+// Message-Class, Message-Size and Content-Location, are carried over.
+// Fields describing the notification PDU itself (Message-Type,
+// Transaction-ID, MMS-Version and Content-Type) are never copied.
+//
+// Everything else retrieved holds, such as its parts, application
+// headers, field order and Content-Type parameters, is kept. The result
+// is a deep copy, as by Clone, so changing it changes neither input.
+func MergeNotification(notif, retrieved *Message) *Message {
+	var merged *Message
+	if retrieved != nil {
+		merged = retrieved.Clone()
+	} else {
+		merged = &Message{}
+	}
+	if merged.Header == nil {
+		merged.Header = make(map[MMSField][]HeaderField)
+	}
+
+	if notif == nil {
+		return merged
+	}
+
+	for f, vals := range notif.Header {
+		switch f {
+		case MessageType, TransactionID, MMSVersion, ContentType:
+			continue
+		}
+		if len(merged.Header[f]) > 0 {
+			continue
+		}
+		for _, v := range vals {
+			merged.Header[f] = append(merged.Header[f], cloneHeaderField(v))
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,114 @@
+package mms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeNotification(t *testing.T) {
+	notif, err := Unmarshal([]byte{
+		0x8c, 0x82, // m-notification-ind
+		0x98, 'n', 0x00, // X-Mms-Transaction-Id: n
+		0x8d, 0x92, // 1.2
+		0x8a, 0x80, // X-Mms-Message-Class: personal
+		0x8e, 0x02, 0x01, 0x00, // X-Mms-Message-Size: 256
+		0x88, 0x05, 0x81, 0x03, 0x03, 0xf4, 0x80, // X-Mms-Expiry: +72h
+		0x83, 'h', 't', 't', 'p', ':', '/', '/', 'x', 0x00, // X-Mms-Content-Location
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retrieved, err := Unmarshal([]byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 'r', 0x00, // X-Mms-Transaction-Id: r
+		0x8d, 0x93, // 1.3
+		0x8a, 0x82, // X-Mms-Message-Class: informational
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := MergeNotification(notif, retrieved)
+
+	relative := 72 * time.Hour
+	size := HeaderUint(256)
+	expect := map[MMSField][]HeaderField{
+		MessageType:     retrieved.Header[MessageType],
+		TransactionID:   []HeaderField{hs("r")},
+		MMSVersion:      []HeaderField{hs("1.3")},
+		MessageClass:    []HeaderField{hs("informational")},
+		MessageSize:     []HeaderField{&size},
+		Expiry:          []HeaderField{&HeaderRelativeOrAbsoluteTime{Relative: &relative}},
+		ContentLocation: []HeaderField{hs("http://x")},
+	}
+
+	if !cmp.Equal(merged.Header, expect) {
+		t.Fatal(cmp.Diff(merged.Header, expect))
+	}
+
+	if _, ok := retrieved.Header[Expiry]; ok {
+		t.Fatal("retrieved message was modified")
+	}
+}
+
+func TestMergeNotificationCopies(t *testing.T) {
+	relative := 72 * time.Hour
+	notif := &Message{
+		Header: map[MMSField][]HeaderField{
+			Expiry:          {&HeaderRelativeOrAbsoluteTime{Relative: &relative}},
+			ContentLocation: {hs("http://x")},
+		},
+	}
+	retrieved := &Message{
+		Header: map[MMSField][]HeaderField{
+			Subject:     {hs("hello")},
+			ContentType: {hs("application/vnd.wap.multipart.related")},
+		},
+		AppHeaders:        map[string]string{"X-A": "a"},
+		ContentTypeParams: map[WellKnownParam]string{StartParam: "<smil>", TypeParam: "application/smil"},
+		FieldOrder:        []MMSField{Subject, ContentType},
+		Parts: []PDUPart{
+			{Header: map[string]string{"Content-ID": "<smil>"}, ContentType: "application/smil", Data: []byte("<smil/>")},
+		},
+	}
+	notifBefore := headerStrings(notif)
+	retrievedBefore := headerStrings(retrieved)
+
+	merged := MergeNotification(notif, retrieved)
+	if diff := cmp.Diff(retrieved.ContentTypeParams, merged.ContentTypeParams); diff != "" {
+		t.Errorf("content type params mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(retrieved.AppHeaders, merged.AppHeaders); diff != "" {
+		t.Errorf("app headers mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(retrieved.FieldOrder, merged.FieldOrder); diff != "" {
+		t.Errorf("field order mismatch (-want +got):\n%s", diff)
+	}
+
+	*merged.Header[Subject][0].(*HeaderString) = "changed"
+	*merged.Header[ContentLocation][0].(*HeaderString) = "http://y"
+	*merged.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime).Relative = time.Minute
+	merged.AppHeaders["X-A"] = "changed"
+	merged.ContentTypeParams[StartParam] = "<other>"
+	merged.FieldOrder[0] = To
+	merged.Parts[0].Data[0] = 'X'
+
+	if diff := cmp.Diff(notifBefore, headerStrings(notif)); diff != "" {
+		t.Errorf("notification changed (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(retrievedBefore, headerStrings(retrieved)); diff != "" {
+		t.Errorf("retrieved message changed (-want +got):\n%s", diff)
+	}
+	if retrieved.AppHeaders["X-A"] != "a" || retrieved.ContentTypeParams[StartParam] != "<smil>" ||
+		retrieved.FieldOrder[0] != Subject || string(retrieved.Parts[0].Data) != "<smil/>" {
+		t.Errorf("retrieved message changed: %+v", retrieved)
+	}
+}
+
+func hs(s string) *HeaderString {
+	h := HeaderString(s)
+	return &h
+}
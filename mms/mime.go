@@ -0,0 +1,503 @@
+package mms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// base64LineLength is the longest base64 line ToMultipart writes, the
+// limit RFC 2045 sets for encoded lines.
+const base64LineLength = 76
+
+// FromMIME builds a Message from an RFC 822 message, mapping the
+// conventional headers (From, To, Cc, Bcc, Subject, Date, Message-ID)
+// and X-Mms-* headers to their MMS fields. Any other header that is
+// not part of the MIME structure is kept in AppHeaders. A multipart
+// body becomes one PDUPart per MIME part, with base64 and
+// quoted-printable content decoded and Content-ID, Content-Location
+// and Content-Disposition filenames carried over. The start and type
+// parameters of a multipart/related body are kept in ContentTypeParams.
+//
+// When the input has no X-Mms-Message-Type or X-Mms-MMS-Version the
+// message defaults to an MMS 1.2 m-send-req, ready for encoding.
+func FromMIME(data []byte) (*Message, error) {
+	mm, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("read mime message err: %w", err)
+	}
+
+	msg := Message{
+		Header: make(map[MMSField][]HeaderField),
+	}
+
+	for name, vals := range mm.Header {
+		for _, val := range vals {
+			if err := msg.setMIMEHeader(name, strings.TrimSpace(val)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, ok := msg.Header[MessageType]; !ok {
+		typ := MSendReq
+		msg.Header[MessageType] = []HeaderField{&typ}
+	}
+	if _, ok := msg.Header[MMSVersion]; !ok {
+		v := HeaderString(defaultVersion)
+		msg.Header[MMSVersion] = []HeaderField{&v}
+	}
+
+	ctHeader := mm.Header.Get("Content-Type")
+	if ctHeader == "" {
+		ctHeader = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(ctHeader)
+	if err != nil {
+		return nil, fmt.Errorf("parse content type err: %w", err)
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		ct := HeaderString(mediaType)
+		msg.Header[ContentType] = []HeaderField{&ct}
+
+		body, err := decodeTransferEncoding(mm.Header.Get("Content-Transfer-Encoding"), mm.Body)
+		if err != nil {
+			return nil, err
+		}
+		part := PDUPart{
+			Header:      make(map[string]string),
+			ContentType: mediaType,
+			Data:        body,
+		}
+		setMIMEPartParams(&part, params)
+		msg.Parts = append(msg.Parts, part)
+		return &msg, nil
+	}
+
+	ct := HeaderString("application/vnd.wap." + strings.Replace(mediaType, "/", ".", 1))
+	msg.Header[ContentType] = []HeaderField{&ct}
+	for name, param := range map[string]WellKnownParam{"start": StartParam, "type": TypeParam} {
+		if v := params[name]; v != "" {
+			if msg.ContentTypeParams == nil {
+				msg.ContentTypeParams = make(map[WellKnownParam]string)
+			}
+			msg.ContentTypeParams[param] = v
+		}
+	}
+
+	mr := multipart.NewReader(mm.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read mime part err: %w", err)
+		}
+
+		part, err := pduPartFromMIME(textproto.MIMEHeader(p.Header), p)
+		if err != nil {
+			return nil, err
+		}
+		msg.Parts = append(msg.Parts, part)
+	}
+
+	return &msg, nil
+}
+
+func (m *Message) setMIMEHeader(name, val string) error {
+	addString := func(f MMSField, s string) {
+		hs := HeaderString(s)
+		m.Header[f] = append(m.Header[f], &hs)
+	}
+	addAddresses := func(f MMSField, list string) {
+		for _, addr := range strings.Split(list, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addString(f, addr)
+			}
+		}
+	}
+
+	switch textproto.CanonicalMIMEHeaderKey(name) {
+	case "Content-Type", "Content-Transfer-Encoding", "Mime-Version":
+	case "From":
+		m.Header[From] = append(m.Header[From], &HeaderFrom{Address: val})
+	case "To":
+		addAddresses(To, val)
+	case "Cc":
+		addAddresses(Cc, val)
+	case "Bcc":
+		addAddresses(Bcc, val)
+	case "Subject":
+		dec := new(mime.WordDecoder)
+		if decoded, err := dec.DecodeHeader(val); err == nil {
+			val = decoded
+		}
+		addString(Subject, val)
+	case "Date":
+		t, err := mail.ParseDate(val)
+		if err != nil {
+			return fmt.Errorf("parse date header err: %w", err)
+		}
+		ht := HeaderTime(t)
+		m.Header[Date] = append(m.Header[Date], &ht)
+	case "Message-Id":
+		addString(MessageID, strings.Trim(val, "<>"))
+	case "X-Mms-Transaction-Id":
+		addString(TransactionID, val)
+	case "X-Mms-Message-Class":
+		addString(MessageClass, val)
+	case "X-Mms-Mms-Version":
+		addString(MMSVersion, val)
+	case "X-Mms-Message-Type":
+		for typ := MSendReq; typ <= MCancelConf; typ++ {
+			if strings.EqualFold(typ.String(), val) {
+				typ := typ
+				m.Header[MessageType] = append(m.Header[MessageType], &typ)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown X-Mms-Message-Type %q", val)
+	case "X-Mms-Priority":
+		var p HeaderPriority
+		switch strings.ToLower(val) {
+		case "low":
+			p = Low
+		case "normal", "medium":
+			p = Medium
+		case "high":
+			p = High
+		default:
+			return fmt.Errorf("unknown X-Mms-Priority %q", val)
+		}
+		m.Header[Priority] = append(m.Header[Priority], &p)
+	default:
+		if m.AppHeaders == nil {
+			m.AppHeaders = make(map[string]string)
+		}
+		m.AppHeaders[name] = val
+	}
+
+	return nil
+}
+
+func pduPartFromMIME(h textproto.MIMEHeader, r io.Reader) (PDUPart, error) {
+	part := PDUPart{
+		Header: make(map[string]string),
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return part, fmt.Errorf("parse part content type err: %w", err)
+	}
+	part.ContentType = mediaType
+	setMIMEPartParams(&part, params)
+
+	if id := h.Get("Content-Id"); id != "" {
+		part.Header[ContentIDPartHeader.String()] = strings.Trim(id, "<>")
+	}
+	if loc := h.Get("Content-Location"); loc != "" {
+		part.Header[ContentLocationPartHeader.String()] = loc
+	}
+	if cd := h.Get("Content-Disposition"); cd != "" {
+		disposition, dparams, err := mime.ParseMediaType(cd)
+		if err == nil {
+			switch disposition {
+			case "attachment":
+				part.Header[ContentDispositionPartHeader.String()] = AttachmentDisposition.String()
+			case "inline":
+				part.Header[ContentDispositionPartHeader.String()] = InlineDisposition.String()
+			case "form-data":
+				part.Header[ContentDispositionPartHeader.String()] = FormDataDisposition.String()
+			default:
+				part.Header[ContentDispositionPartHeader.String()] = disposition
+			}
+			part.FileName = dparams["filename"]
+		}
+	}
+
+	part.Data, err = decodeTransferEncoding(h.Get("Content-Transfer-Encoding"), r)
+	if err != nil {
+		return part, err
+	}
+
+	return part, nil
+}
+
+// setMIMEPartParams maps MIME content type parameters to the part
+// header keys used by the decoder.
+func setMIMEPartParams(part *PDUPart, params map[string]string) {
+	for k, v := range params {
+		switch k {
+		case "name":
+			part.Header["Name"] = v
+		case "charset":
+			part.Header["Character-Set"] = v
+		case "type":
+			part.Header["Content-Type"] = v
+		case "start":
+			part.Header["Start"] = v
+		}
+	}
+}
+
+func decodeTransferEncoding(cte string, r io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(cte)) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s body err: %w", cte, err)
+	}
+	return data, nil
+}
+
+// Headers returns the message header fields under their conventional
+// RFC 822 names, the header counterpart of ToMultipart. From, To, Cc
+// and Bcc addresses have their "/TYPE=" suffix removed, leaving a plain
+// phone number or e-mail address, and multiple recipients share one
+// comma separated header. Date is formatted as RFC 1123 with a numeric
+// zone, a non-ASCII Subject is Q-encoded and Message-ID is enclosed in
+// angle brackets. The X-Mms-* fields that FromMIME reads are included
+// too, so FromMIME restores them.
+func (m *Message) Headers() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+
+	addresses := func(name string, f MMSField) {
+		var list []string
+		for _, s := range m.stringFields(f) {
+			list = append(list, ParseAddress(s).Value)
+		}
+		if len(list) > 0 {
+			h.Set(name, strings.Join(list, ", "))
+		}
+	}
+
+	if from := m.From(); from != "" && !m.FromInsert() {
+		h.Set("From", ParseAddress(from).Value)
+	}
+	addresses("To", To)
+	addresses("Cc", Cc)
+	addresses("Bcc", Bcc)
+	if subject := m.Subject(); subject != "" {
+		h.Set("Subject", mime.QEncoding.Encode("utf-8", subject))
+	}
+	if date, ok := m.Date(); ok {
+		h.Set("Date", date.Format(time.RFC1123Z))
+	}
+	if id := m.stringField(MessageID); id != "" {
+		h.Set("Message-ID", "<"+id+">")
+	}
+
+	for _, f := range []MMSField{MessageType, TransactionID, MMSVersion, MessageClass, Priority} {
+		if v := m.field(f); v != nil {
+			h.Set("X-Mms-"+mimeFieldName[f], v.String())
+		}
+	}
+
+	return h
+}
+
+// mimeFieldName maps the fields Headers writes as X-Mms-* headers to
+// the rest of their header name.
+var mimeFieldName = map[MMSField]string{
+	MessageType:   "Message-Type",
+	TransactionID: "Transaction-Id",
+	MMSVersion:    "MMS-Version",
+	MessageClass:  "Message-Class",
+	Priority:      "Priority",
+}
+
+// ToMultipart encodes the message parts as a MIME multipart body, the
+// counterpart of FromMIME for the body. It returns the body and the
+// Content-Type value to send it with, including the boundary.
+//
+// The multipart subtype follows the message Content-Type, so an
+// application/vnd.wap.multipart.related message becomes
+// multipart/related; anything else becomes multipart/mixed. For a
+// related message the Start parameter is carried over as the start
+// parameter, and the type parameter is the Type parameter or, failing
+// that, the content type of the start or presentation part.
+//
+// Each part is written with its Content-Type (with charset and name
+// parameters), Content-ID, Content-Location and Content-Disposition,
+// and its body base64 encoded.
+func (m *Message) ToMultipart() ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	subtype := "mixed"
+	if ct := strings.ToLower(m.ContentType()); isMultipart(ct) {
+		subtype = ct[strings.LastIndexAny(ct, "./")+1:]
+	}
+	params := map[string]string{
+		"boundary": mw.Boundary(),
+	}
+	if subtype == "related" {
+		if start := contentRef(m.ContentTypeParams[StartParam]); start != "" {
+			params["start"] = "<" + start + ">"
+		}
+		typ := m.ContentTypeParams[TypeParam]
+		if typ == "" {
+			if p, ok := m.startPart(); ok {
+				typ = p.ContentType
+			} else if p, ok := m.PresentationPart(); ok {
+				typ = p.ContentType
+			}
+		}
+		if typ != "" {
+			params["type"] = typ
+		}
+	}
+	contentType := mime.FormatMediaType("multipart/"+subtype, params)
+	if contentType == "" {
+		return nil, "", fmt.Errorf("invalid multipart content type parameters %q", params)
+	}
+
+	for i := range m.Parts {
+		if err := writeMIMEPart(mw, &m.Parts[i]); err != nil {
+			return nil, "", fmt.Errorf("write part %d err: %w", i, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), contentType, nil
+}
+
+// ToMIME encodes the message as an RFC 822 message, the counterpart of
+// FromMIME. The header is that of Headers plus any AppHeaders, with
+// MIME-Version and the Content-Type from ToMultipart, written in sorted
+// order; the body is the multipart body from ToMultipart.
+func (m *Message) ToMIME() ([]byte, error) {
+	body, contentType, err := m.ToMultipart()
+	if err != nil {
+		return nil, err
+	}
+
+	h := m.Headers()
+	for name, v := range m.AppHeaders {
+		if h.Get(name) == "" {
+			h.Set(name, v)
+		}
+	}
+	h.Set("MIME-Version", "1.0")
+	h.Set("Content-Type", contentType)
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		for _, v := range h[name] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, v)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// writeMIMEPart writes p as the next part of mw.
+func writeMIMEPart(mw *multipart.Writer, p *PDUPart) error {
+	mediaType := p.ContentType
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	params := make(map[string]string)
+	if cs := p.Header["Character-Set"]; cs != "" {
+		params["charset"] = cs
+	}
+	if name := p.Header["Name"]; name != "" {
+		params["name"] = name
+	}
+	ct := mime.FormatMediaType(mediaType, params)
+	if ct == "" {
+		return fmt.Errorf("invalid content type %q", mediaType)
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", ct)
+	if id := contentRef(p.Header[ContentIDPartHeader.String()]); id != "" {
+		h.Set("Content-ID", "<"+id+">")
+	}
+	if loc := p.Header[ContentLocationPartHeader.String()]; loc != "" {
+		h.Set("Content-Location", loc)
+	}
+	if cd := mimeDisposition(p); cd != "" {
+		h.Set("Content-Disposition", cd)
+	}
+	h.Set("Content-Transfer-Encoding", "base64")
+
+	data, err := p.DecodedData()
+	if err != nil {
+		return err
+	}
+
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > base64LineLength {
+		io.WriteString(w, enc[:base64LineLength]+"\r\n")
+		enc = enc[base64LineLength:]
+	}
+	_, err = io.WriteString(w, enc)
+	return err
+}
+
+// mimeDisposition returns the Content-Disposition value for p, or ""
+// if it has none. A part with a file name but no disposition is an
+// attachment.
+func mimeDisposition(p *PDUPart) string {
+	disposition := p.Header[ContentDispositionPartHeader.String()]
+	if disposition == "" {
+		disposition = p.Header[DepContentDispositionPartHeader.String()]
+	}
+	switch disposition {
+	case "":
+		if p.FileName == "" {
+			return ""
+		}
+		disposition = "attachment"
+	case AttachmentDisposition.String():
+		disposition = "attachment"
+	case InlineDisposition.String():
+		disposition = "inline"
+	case FormDataDisposition.String():
+		disposition = "form-data"
+	default:
+		disposition = strings.ToLower(disposition)
+	}
+
+	var params map[string]string
+	if p.FileName != "" {
+		params = map[string]string{"filename": p.FileName}
+	}
+	if cd := mime.FormatMediaType(disposition, params); cd != "" {
+		return cd
+	}
+	return disposition
+}
@@ -0,0 +1,283 @@
+package mms
+
+import (
+	"bytes"
+	"mime"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromMIME(t *testing.T) {
+	eml := strings.ReplaceAll(`From: +15551231234/TYPE=PLMN
+To: +15550001111/TYPE=PLMN, bob@example.com/TYPE=RFC822
+Subject: =?UTF-8?Q?caf=C3=A9?=
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-ID: <abc@example.com>
+X-Mms-Message-Class: personal
+X-Gateway: gw1
+MIME-Version: 1.0
+Content-Type: multipart/related; boundary="b1"; type="application/smil"; start="<smil>"
+
+--b1
+Content-Type: application/smil
+Content-ID: <smil>
+
+<smil/>
+--b1
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+Content-Location: text.txt
+
+caf=C3=A9
+--b1
+Content-Type: image/jpeg; name="cat.jpg"
+Content-Transfer-Encoding: base64
+Content-ID: <img1>
+Content-Disposition: attachment; filename="cat.jpg"
+
+/9j/2Q==
+--b1--
+`, "\n", "\r\n")
+
+	msg, err := FromMIME([]byte(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := HeaderTime(time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone("", 0)))
+	typ := MSendReq
+	expectHeader := map[MMSField][]HeaderField{
+		From:         {&HeaderFrom{Address: "+15551231234/TYPE=PLMN"}},
+		To:           {hs("+15550001111/TYPE=PLMN"), hs("bob@example.com/TYPE=RFC822")},
+		Subject:      {hs("café")},
+		Date:         {&date},
+		MessageID:    {hs("abc@example.com")},
+		MessageClass: {hs("personal")},
+		MessageType:  {&typ},
+		MMSVersion:   {hs("1.2")},
+		ContentType:  {hs("application/vnd.wap.multipart.related")},
+	}
+	opt := cmp.Comparer(func(a, b HeaderTime) bool {
+		return time.Time(a).Equal(time.Time(b))
+	})
+	if !cmp.Equal(msg.Header, expectHeader, opt) {
+		t.Fatal(cmp.Diff(msg.Header, expectHeader, opt))
+	}
+
+	if !cmp.Equal(msg.AppHeaders, map[string]string{"X-Gateway": "gw1"}) {
+		t.Fatalf("unexpected app headers %v", msg.AppHeaders)
+	}
+
+	expectParts := []PDUPart{
+		{
+			Header:      map[string]string{"Content-ID": "smil"},
+			ContentType: "application/smil",
+			Data:        []byte("<smil/>"),
+		},
+		{
+			Header:      map[string]string{"Character-Set": "utf-8", "Content-Location": "text.txt"},
+			ContentType: "text/plain",
+			Data:        []byte("café"),
+		},
+		{
+			Header: map[string]string{
+				"Name":                "cat.jpg",
+				"Content-ID":          "img1",
+				"Content-Disposition": "AttachmentDisposition",
+			},
+			FileName:    "cat.jpg",
+			ContentType: "image/jpeg",
+			Data:        []byte{0xff, 0xd8, 0xff, 0xd9},
+		},
+	}
+	if !cmp.Equal(msg.Parts, expectParts) {
+		t.Fatal(cmp.Diff(msg.Parts, expectParts))
+	}
+}
+
+func TestToMultipart(t *testing.T) {
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			ContentType: {hs("application/vnd.wap.multipart.related")},
+		},
+		ContentTypeParams: map[WellKnownParam]string{
+			StartParam: "<smil>",
+		},
+		Parts: []PDUPart{
+			{
+				Header:      map[string]string{"Content-ID": "<smil>"},
+				ContentType: "application/smil",
+				Data:        []byte("<smil/>"),
+			},
+			{
+				Header:      map[string]string{"Character-Set": "utf-8", "Content-Location": "text.txt"},
+				ContentType: "text/plain",
+				Data:        []byte("café"),
+			},
+			{
+				Header: map[string]string{
+					"Name":                "cat.jpg",
+					"Content-ID":          "img1",
+					"Content-Disposition": "AttachmentDisposition",
+				},
+				FileName:    "cat.jpg",
+				ContentType: "image/jpeg",
+				Data:        bytes.Repeat([]byte{0xff, 0xd8, 0xff, 0xd9}, 100),
+			},
+		},
+	}
+
+	body, contentType, err := msg.ToMultipart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/related" || params["start"] != "<smil>" || params["type"] != "application/smil" || params["boundary"] == "" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	for _, line := range strings.Split(string(body), "\r\n") {
+		if len(line) > base64LineLength {
+			t.Fatalf("line longer than %d: %q", base64LineLength, line)
+		}
+	}
+
+	eml := "Content-Type: " + contentType + "\r\n\r\n" + string(body)
+	got, err := FromMIME([]byte(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]PDUPart(nil), msg.Parts...)
+	want[0].Header = map[string]string{"Content-ID": "smil"}
+	if diff := cmp.Diff(want, got.Parts); diff != "" {
+		t.Fatalf("parts mismatch (-want +got):\n%s", diff)
+	}
+
+	packet, err := Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantParams := map[WellKnownParam]string{
+		StartParam: "<smil>",
+		TypeParam:  "application/smil",
+	}
+	if diff := cmp.Diff(wantParams, decoded.ContentTypeParams); diff != "" {
+		t.Fatalf("content type params mismatch (-want +got):\n%s", diff)
+	}
+	if p, ok := decoded.startPart(); !ok || p.ContentType != "application/smil" {
+		t.Fatalf("start part not found after round trip")
+	}
+}
+
+func TestToMIME(t *testing.T) {
+	typ := MSendReq
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-1")},
+			MMSVersion:    {hs("1.2")},
+			From:          {hs("+15551231234/TYPE=PLMN")},
+			To:            {hs("+15550001111/TYPE=PLMN")},
+			Subject:       {hs("café")},
+			ContentType:   {hs("application/vnd.wap.multipart.mixed")},
+		},
+		AppHeaders: map[string]string{"X-Carrier": "example"},
+		Parts: []PDUPart{
+			{
+				Header:      map[string]string{"Character-Set": "utf-8", "Content-Location": "text.txt"},
+				ContentType: "text/plain",
+				Data:        []byte("hello"),
+			},
+		},
+	}
+
+	eml, err := msg.ToMIME()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromMIME(eml)
+	if err != nil {
+		t.Fatalf("parse %q err: %s", eml, err)
+	}
+
+	want := map[string][]string{
+		"Message-Type":   {"m-send-req"},
+		"Transaction-ID": {"tx-1"},
+		"MMS-Version":    {"1.2"},
+		"From":           {"+15551231234"},
+		"To":             {"+15550001111"},
+		"Subject":        {"café"},
+		"Content-Type":   {"application/vnd.wap.multipart.mixed"},
+	}
+	if diff := cmp.Diff(want, headerStrings(got)); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.AppHeaders, got.AppHeaders); diff != "" {
+		t.Errorf("app header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(msg.Parts, got.Parts); diff != "" {
+		t.Errorf("parts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	typ := MRetrieveConf
+	priority := High
+	date := HeaderTime(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC))
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-1")},
+			MMSVersion:    {hs("1.2")},
+			MessageID:     {hs("abc@example.com")},
+			Date:          {&date},
+			From:          {hs("+15551231234/TYPE=PLMN")},
+			To:            {hs("+15550001111/TYPE=PLMN"), hs("bob@example.com")},
+			Subject:       {hs("café")},
+			Priority:      {&priority},
+		},
+	}
+
+	got := msg.Headers()
+	want := textproto.MIMEHeader{
+		"From":                 {"+15551231234"},
+		"To":                   {"+15550001111, bob@example.com"},
+		"Subject":              {"=?utf-8?q?caf=C3=A9?="},
+		"Date":                 {"Mon, 02 Jan 2006 15:04:05 +0000"},
+		"Message-Id":           {"<abc@example.com>"},
+		"X-Mms-Message-Type":   {"m-retrieve-conf"},
+		"X-Mms-Transaction-Id": {"tx-1"},
+		"X-Mms-Mms-Version":    {"1.2"},
+		"X-Mms-Priority":       {"high"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("headers mismatch (-want +got):\n%s", diff)
+	}
+
+	var eml strings.Builder
+	for name, vals := range got {
+		eml.WriteString(name + ": " + vals[0] + "\r\n")
+	}
+	eml.WriteString("\r\nhi")
+	back, err := FromMIME([]byte(eml.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Subject() != "café" || back.MessageType() != MRetrieveConf || back.Priority() != High {
+		t.Fatalf("headers not restored: %s", back)
+	}
+	if d, _ := back.Date(); !d.Equal(time.Time(date)) {
+		t.Fatalf("date got %v", d)
+	}
+}
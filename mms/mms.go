@@ -3,50 +3,353 @@ package mms
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Message struct {
 	Header map[MMSField][]HeaderField
 	Parts  []PDUPart
+
+	// AppHeaders holds any textual Application-headers interleaved
+	// with the MMS headers. It is nil when there are none.
+	AppHeaders map[string]string
+
+	// UnknownHeaders holds the header fields with no known grammar
+	// that were skipped in lenient mode, in the order they appeared.
+	UnknownHeaders []UnknownHeader
+
+	// ContentTypeParams holds the well-known parameters of the top
+	// level Content-Type of a multipart message, such as the Start and
+	// Type parameters of multipart/related. It is nil when there are
+	// none. A single part message has its parameters recorded on the
+	// part instead.
+	ContentTypeParams map[WellKnownParam]string
+
+	// RawHeaders holds the encoded bytes of each header field, from
+	// its field code to the end of its value, when decoded with
+	// DecodeOptions.RawHeaders. A repeated field has the bytes of each
+	// occurrence appended in order. It is nil otherwise.
+	RawHeaders map[MMSField][]byte
+
+	// FieldOrder lists the header fields in the order they were
+	// decoded, with a repeated field listed once per occurrence, so
+	// that the nth occurrence of a field names Header[field][n]. It is
+	// nil for a message that wasn't decoded. Fields pairs the two up.
+	FieldOrder []MMSField
+
+	// PartErrors lists the multipart entries that failed to decode
+	// when decoded with DecodeOptions.SkipBadParts. It is nil
+	// otherwise.
+	PartErrors []*PartError
+}
+
+// PartError records a multipart entry that failed to decode.
+type PartError struct {
+	// Index is the position of the entry among all the entries in
+	// the body, including those that failed.
+	Index int
+	Err   error
+}
+
+func (e *PartError) Error() string {
+	return fmt.Sprintf("part %d: %s", e.Index, e.Err)
+}
+
+func (e *PartError) Unwrap() error {
+	return e.Err
+}
+
+// FieldValue is one occurrence of a header field.
+type FieldValue struct {
+	Field MMSField
+	Value HeaderField
+}
+
+// Fields returns the header field values in order: those listed in
+// FieldOrder first, in that order, then any others in the order
+// Marshal would otherwise write them, with Content-Type last.
+func (m *Message) Fields() []FieldValue {
+	var out []FieldValue
+	used := make(map[MMSField]int)
+	for _, f := range m.FieldOrder {
+		vals := m.Header[f]
+		if f == ContentType || used[f] >= len(vals) {
+			continue
+		}
+		out = append(out, FieldValue{Field: f, Value: vals[used[f]]})
+		used[f]++
+	}
+	for _, f := range headerOrder(m.Header) {
+		for _, v := range m.Header[f][used[f]:] {
+			out = append(out, FieldValue{Field: f, Value: v})
+		}
+	}
+	return out
+}
+
+// Walk calls fn for each header field value in the order of Fields,
+// stopping at and returning the first error fn returns.
+func (m *Message) Walk(fn func(field MMSField, value HeaderField) error) error {
+	for _, fv := range m.Fields() {
+		if err := fn(fv.Field, fv.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkParts calls fn for each part in order, stopping at and returning
+// the first error fn returns. Changes fn makes through p are made to
+// the message's part.
+func (m *Message) WalkParts(fn func(index int, p *PDUPart) error) error {
+	for i := range m.Parts {
+		if err := fn(i, &m.Parts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RawHeader returns the encoded bytes of field as recorded in
+// RawHeaders, or nil if they weren't recorded or the field is absent.
+func (m *Message) RawHeader(field MMSField) []byte {
+	return m.RawHeaders[field]
 }
 
 type HeaderField interface {
 	String() string
 }
 
+// ErrTruncated is returned, possibly wrapped, when a PDU ends before a
+// value, length or part it declares is complete, including when the
+// input is empty.
+var ErrTruncated = errors.New("truncated mms pdu")
+
+// Errors wrapped by the decoder when a PDU is malformed. The wrapping
+// error adds the position of the offending octet. ErrInvalidValue
+// covers malformed values without a more specific sentinel.
+var (
+	ErrInvalidShortInt = errors.New("invalid short int")
+	ErrInvalidBoolean  = errors.New("invalid boolean value")
+	ErrUnknownField    = errors.New("unknown mms field type")
+	ErrInvalidValue    = errors.New("invalid mms value")
+)
+
+// ErrTooLarge is returned when a PDU declares a value or part longer
+// than the decoder's MaxLength.
+var ErrTooLarge = errors.New("mms value too large")
+
+// DefaultMaxLength is the MaxLength used when DecodeOptions.MaxLength
+// is zero.
+const DefaultMaxLength = 4 << 20
+
 func Unmarshal(packet []byte) (*Message, error) {
-	rr := bytes.NewReader(packet)
+	return UnmarshalWithOptions(packet, DecodeOptions{})
+}
 
-	dec := decoder{
-		r:      bufio.NewReader(rr),
-		seeker: rr,
+// DecodeOptions configures how a PDU is decoded.
+type DecodeOptions struct {
+	// DefaultCharset, if set, overrides the charset implied by the
+	// message's MMS version for text parts that don't declare one.
+	DefaultCharset string
+
+	// Lenient tolerates known encoder mistakes instead of failing. A
+	// multipart entry whose declared header length is a few bytes
+	// short of its actual headers is decoded by extending the header
+	// block until it parses; when the length is too long instead, the
+	// headers that decode are kept and the rest of the block is
+	// skipped. Header fields with no known grammar are skipped on a
+	// best-effort basis and recorded in Message.UnknownHeaders.
+	// Expiry and Delivery-Time values sent as a bare Long-integer date
+	// are accepted.
+	Lenient bool
+
+	// MaxLength caps the length of any single value or part body in
+	// bytes. Declared lengths are checked against it, and against the
+	// input that remains when its size is known, before anything is
+	// allocated. Zero means DefaultMaxLength.
+	MaxLength int
+
+	// RawHeaders records the encoded bytes of each header field in
+	// Message.RawHeaders. Only input held in memory, as passed to
+	// UnmarshalWithOptions or Decoder.ResetBytes, is recorded.
+	RawHeaders bool
+
+	// SkipBadParts keeps decoding after a multipart entry fails to
+	// decode, for recovering what can be from a damaged message. The
+	// failure is recorded in Message.PartErrors and the entry is left
+	// out of Parts. An entry with bad headers is stepped over using
+	// its declared lengths; after a failure that leaves the position
+	// of the next entry unknown, such as a truncated entry, the rest
+	// of the body is dropped.
+	SkipBadParts bool
+}
+
+// UnmarshalWithOptions decodes packet like Unmarshal, configured by opts.
+func UnmarshalWithOptions(packet []byte, opts DecodeOptions) (*Message, error) {
+	var msg Message
+	if err := decodeInto(&msg, newBytesDecoder(packet), opts); err != nil {
+		return nil, err
 	}
+	return &msg, nil
+}
 
-	hdr, err := dec.decodeHeader()
-	if err != nil {
+// UnmarshalLenient decodes packet in lenient mode, skipping header
+// fields that have no known grammar instead of failing. The skipped
+// fields and their raw values are listed in Message.UnknownHeaders.
+//
+// The end of an unknown value is found from its first octet. Fields
+// whose values are Text-strings, Short-integer tokens, Long-integers
+// or any Value-length prefixed form (encoded strings, dates with a
+// token, content types with parameters) are skipped safely. A field
+// whose value is a bare Uintvar-integer or other unprefixed multi-octet
+// encoding can't be delimited and is likely to corrupt the fields that
+// follow it.
+//
+// If decoding still fails, the partially decoded Message is returned
+// along with the error.
+func UnmarshalLenient(packet []byte) (*Message, error) {
+	var msg Message
+	err := decodeInto(&msg, newBytesDecoder(packet), DecodeOptions{Lenient: true})
+	return &msg, err
+}
+
+// UnmarshalContext decodes packet like Unmarshal, checking ctx before
+// the body and each of its parts. If ctx is done the decode stops and
+// ctx.Err() is returned.
+func UnmarshalContext(ctx context.Context, packet []byte) (*Message, error) {
+	var msg Message
+	dec := newBytesDecoder(packet)
+	dec.ctx = ctx
+	if err := decodeInto(&msg, dec, DecodeOptions{}); err != nil {
 		return nil, err
 	}
+	return &msg, nil
+}
+
+// decodeInto decodes the PDU read by dec into m, reusing m's header
+// map and parts slice when they are already allocated.
+func decodeInto(m *Message, dec *decoder, opts DecodeOptions) error {
+	if _, err := dec.r.Peek(1); err == io.EOF {
+		return ErrTruncated
+	}
+	dec.lenient = opts.Lenient
+	dec.maxLength = opts.MaxLength
+	dec.rawHeaders = opts.RawHeaders && dec.sr != nil
+	dec.skipBadParts = opts.SkipBadParts
+
+	if m.Header == nil {
+		m.Header = make(map[MMSField][]HeaderField)
+	}
+	for f := range m.Header {
+		delete(m.Header, f)
+	}
+	for k := range m.AppHeaders {
+		delete(m.AppHeaders, k)
+	}
+	m.UnknownHeaders = m.UnknownHeaders[:0]
+	for f := range m.RawHeaders {
+		delete(m.RawHeaders, f)
+	}
+	m.FieldOrder = m.FieldOrder[:0]
+
+	err := dec.decodeHeader(m)
+	if err != nil {
+		return dec.truncated(err)
+	}
+
+	if err := dec.ctxErr(); err != nil {
+		return err
+	}
 
-	parts, err := dec.decodeBody()
+	var parts []PDUPart
+	if ct := m.Header[ContentType]; len(ct) > 0 && !isMultipart(ct[0].String()) {
+		parts, err = dec.decodeSinglePart(m.Parts[:0], ct[0].String())
+	} else {
+		parts, err = dec.decodeBody(m.Parts[:0])
+	}
 	if err != nil && err != io.EOF {
-		return nil, err
+		return dec.truncated(err)
+	}
+	if len(parts) == 0 {
+		parts = nil
+	}
+	if len(m.AppHeaders) == 0 {
+		m.AppHeaders = nil
+	}
+	if len(m.UnknownHeaders) == 0 {
+		m.UnknownHeaders = nil
+	}
+	if len(m.RawHeaders) == 0 {
+		m.RawHeaders = nil
+	}
+	if len(m.FieldOrder) == 0 {
+		m.FieldOrder = nil
+	}
+	m.PartErrors = dec.partErrors
+	m.ContentTypeParams = nil
+	if ct := m.Header[ContentType]; len(ct) > 0 && isMultipart(ct[0].String()) && len(dec.contentTypeParams) > 0 {
+		m.ContentTypeParams = dec.contentTypeParams
 	}
 
-	msg := Message{
-		Header: hdr,
-		Parts:  parts,
+	defaultCharset := opts.DefaultCharset
+	if defaultCharset == "" {
+		defaultCharset = versionDefaultCharset(m.Header)
+	}
+	for i := range parts {
+		parts[i].DefaultCharset = defaultCharset
 	}
 
-	return &msg, nil
+	m.Parts = parts
+
+	return nil
 }
 
 type decoder struct {
-	r      *bufio.Reader
-	seeker io.Seeker
-	err    error
+	r   byteReader
+	err error
+
+	// For in-memory input sr is the reader; for a stream br is, and
+	// count tracks the bytes it has taken from the stream.
+	sr    *sliceReader
+	br    *bufio.Reader
+	count *countingReader
+
+	// size is the total length of the input, or -1 if unknown.
+	size int64
+
+	// lenient mirrors DecodeOptions.Lenient.
+	lenient bool
+
+	// maxLength mirrors DecodeOptions.MaxLength.
+	maxLength int
+
+	// rawHeaders is set when DecodeOptions.RawHeaders is and the
+	// input is in memory.
+	rawHeaders bool
+
+	// ctx, if set, is checked between multipart entries.
+	ctx context.Context
+
+	// skipBadParts mirrors DecodeOptions.SkipBadParts, and partErrors
+	// collects the failures it skips.
+	skipBadParts bool
+	partErrors   []*PartError
+
+	// contentTypeParams holds the parameters of the top level
+	// Content-Type header once it has been decoded.
+	contentTypeParams map[WellKnownParam]string
+
+	// untypedParams holds the untyped parameters, by name, of the
+	// last content type value decoded.
+	untypedParams map[string]string
 }
 
 type PDUPart struct {
@@ -54,80 +357,214 @@ type PDUPart struct {
 	FileName    string
 	ContentType string
 	Data        []byte
+
+	// DefaultCharset is the charset assumed for text when the part
+	// does not declare one. It is set by the decoder.
+	DefaultCharset string
+
+	// Params holds the well-known content type parameters that have
+	// no Header entry, such as SizeParam or MaxAgeParam. Integer
+	// values are in decimal and deprecated parameter codes are
+	// recorded under their replacements. Parameters with codes the
+	// decoder doesn't know are kept under their code. It is nil if
+	// there are none. Untyped parameters, which have a name instead
+	// of a code, are kept in Header as "Content-Type-" and the name.
+	Params map[WellKnownParam]string
 }
 
-func (d *decoder) decodeBody() ([]PDUPart, error) {
-	entries, err := d.decodeVarUint()
+// setContentTypeParams records the well-known content type parameters
+// in the part header, and those without a header key in p.Params.
+func (p *PDUPart) setContentTypeParams(params map[WellKnownParam]string) {
+	p.Params = nil
+	for k, v := range params {
+		switch k {
+		case TypeParam:
+			p.Header["Content-Type"] = v
+		case NameParam:
+			p.Header["Name"] = v
+		case CharsetParam:
+			p.Header["Character-Set"] = v
+		case StartParam:
+			p.Header["Start"] = v
+		case QParam:
+			p.Header["Q"] = v
+		case CreationDateParam, ModificationDateParam, ReadDateParam:
+			// recorded by setDateParams
+		default:
+			if p.Params == nil {
+				p.Params = make(map[WellKnownParam]string)
+			}
+			p.Params[k] = v
+		}
+	}
+	setDateParams(p.Header, params)
+}
+
+// contentTypeParamPrefix starts the part header keys of untyped content
+// type parameters, e.g. "Content-Type-Format".
+const contentTypeParamPrefix = "Content-Type-"
+
+// setUntypedParams records the untyped content type parameters in a
+// part header under their names.
+func setUntypedParams(hdr map[string]string, params map[string]string) {
+	for k, v := range params {
+		hdr[contentTypeParamPrefix+k] = v
+	}
+}
+
+// setDateParams records the date parameters of a content type value in
+// a part header.
+func setDateParams(hdr map[string]string, params map[WellKnownParam]string) {
+	for k, v := range params {
+		switch k {
+		case CreationDateParam:
+			hdr["Creation-Date"] = v
+		case ModificationDateParam:
+			hdr["Modification-Date"] = v
+		case ReadDateParam:
+			hdr["Read-Date"] = v
+		}
+	}
+}
+
+// dispositionParamPrefix starts the part header keys of Content-Disposition
+// parameters, e.g. "Content-Disposition-Size".
+const dispositionParamPrefix = "Content-Disposition-"
+
+// setDispositionParams records the parameters of a Content-Disposition
+// value in a part header, including its dates, e.g.
+// "Content-Disposition-Creation-Date". The filename is kept in
+// PDUPart.FileName, so it is skipped.
+func setDispositionParams(hdr map[string]string, params map[WellKnownParam]string) {
+	for k, v := range params {
+		if k == FilenameParam {
+			continue
+		}
+		hdr[dispositionParamPrefix+k.String()] = v
+	}
+}
+
+// decodeSinglePart decodes the body of a message whose top level
+// content type is not multipart. The whole remaining body is the
+// content of a single part.
+func (d *decoder) decodeSinglePart(parts []PDUPart, contentType string) ([]PDUPart, error) {
+	body, err := io.ReadAll(io.LimitReader(d.r, int64(d.limit())+1))
 	if err != nil {
-		return nil, err
+		return parts, err
+	}
+	if len(body) > d.limit() {
+		return parts, fmt.Errorf("message body exceeds %d bytes: %w", d.limit(), ErrTooLarge)
 	}
 
-	var parts []PDUPart
+	var partHeader map[string]string
+	if len(parts) < cap(parts) {
+		partHeader = parts[:len(parts)+1][len(parts)].Header
+		for k := range partHeader {
+			delete(partHeader, k)
+		}
+	}
+	if partHeader == nil {
+		partHeader = make(map[string]string)
+	}
+
+	part := PDUPart{
+		Header:      partHeader,
+		ContentType: contentType,
+		Data:        body,
+	}
+	part.setContentTypeParams(d.contentTypeParams)
+	setUntypedParams(part.Header, d.untypedParams)
+	part.FileName = part.Header["Name"]
+
+	return append(parts, part), nil
+}
+
+// isMultipart reports whether contentType is a multipart media type.
+func isMultipart(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "application/vnd.wap.multipart.") || strings.HasPrefix(ct, "multipart/")
+}
+
+// decodeBody appends the decoded multipart entries to parts. Header
+// maps left in the spare capacity of parts are cleared and reused.
+//
+// WAP-230 section 8.5.3 encodes the entry count and both lengths as
+// Uintvar-integers; the Value-length form is not used here:
+//
+//	Multipart = nEntries *MultipartEntry
+//	nEntries = Uintvar-integer
+//	MultipartEntry = HeadersLen DataLen ContentType Headers Data
+//	HeadersLen = Uintvar-integer
+//	DataLen = Uintvar-integer
+func (d *decoder) decodeBody(parts []PDUPart) ([]PDUPart, error) {
+	entries, err := d.decodeVarUint()
+	if err != nil {
+		// io.EOF here is a message with no body.
+		return parts, err
+	}
 
 	for i := 0; i < int(entries); i++ {
+		if err := d.ctxErr(); err != nil {
+			return nil, err
+		}
+
+		var partHeader map[string]string
+		if len(parts) < cap(parts) {
+			partHeader = parts[:len(parts)+1][len(parts)].Header
+			for k := range partHeader {
+				delete(partHeader, k)
+			}
+		}
+		if partHeader == nil {
+			partHeader = make(map[string]string)
+		}
+
 		part := PDUPart{
-			Header: make(map[string]string),
+			Header: partHeader,
 		}
 		headerLen, err := d.decodeVarUint()
 		if err != nil {
-			return nil, err
+			return d.badPart(parts, i, err)
 		}
 		dataLen, err := d.decodeVarUint()
 		if err != nil {
-			return nil, err
+			return d.badPart(parts, i, err)
 		}
 
-		headerBuf := make([]byte, headerLen)
-		n, err := io.ReadFull(d.r, headerBuf)
-		if err != nil {
-			return nil, fmt.Errorf("read mime part header err: %w, n:%d want:%d", err, n, headerLen)
-		}
-		rr := bytes.NewReader(headerBuf)
-		tmpDecoder := decoder{
-			r:      bufio.NewReader(rr),
-			seeker: rr,
+		if err := d.checkLength(uint64(headerLen)+uint64(dataLen), "mime part"); err != nil {
+			return d.badPart(parts, i, err)
 		}
 
-		s, params, err := tmpDecoder.decodeContentTypeValue()
+		headerBuf, err := d.readValue(headerLen)
 		if err != nil {
-			return nil, fmt.Errorf("decode content type for mime part err: %w", err)
+			return d.badPart(parts, i, fmt.Errorf("read mime part header err: %w, want:%d", err, headerLen))
 		}
 
-		part.ContentType = s
-		if typ, ok := params[TypeParam]; ok {
-			part.Header["Content-Type"] = typ
-		}
-		for k, v := range params {
-			switch k {
-			case TypeParam:
-				part.Header["Content-Type"] = v
-			case NameParam:
-				part.Header["Name"] = v
-			case CharsetParam:
-				part.Header["Character-Set"] = v
-			case StartParam:
-				part.Header["Start"] = v
+		err = part.decodeHeaders(headerBuf, false)
+		if err != nil && d.lenient {
+			err = d.extendPartHeaders(&part, headerBuf, err)
+			if err != nil {
+				// The declared length may instead be too long, so
+				// keep the headers that decode and skip the rest.
+				err = part.decodeHeaders(headerBuf, true)
 			}
 		}
-
-		if int(tmpDecoder.offset()) < len(headerBuf) {
-			return nil, fmt.Errorf("pending part headers")
-		}
-
-		filename, headers, err := tmpDecoder.decodePartHeaders()
 		if err != nil {
-			return nil, fmt.Errorf("parse mime part header err: %w", err)
-		}
-
-		part.FileName = filename
-		for k, v := range headers {
-			part.Header[k] = v
+			if !d.skipBadParts {
+				return nil, err
+			}
+			d.partErrors = append(d.partErrors, &PartError{Index: i, Err: err})
+			if _, err := d.r.Discard(int(dataLen)); err != nil {
+				// Its data is cut short too, so nothing follows.
+				return parts, nil
+			}
+			continue
 		}
 
 		body := make([]byte, dataLen)
 		_, err = io.ReadFull(d.r, body)
 		if err != nil {
-			return nil, fmt.Errorf("read mime part body err %w", err)
+			return d.badPart(parts, i, fmt.Errorf("read mime part body err %w", err))
 		}
 
 		part.Data = body
@@ -138,24 +575,155 @@ func (d *decoder) decodeBody() ([]PDUPart, error) {
 	return parts, nil
 }
 
+// truncated reports err as ErrTruncated if the input ran out while a
+// value was being read, as opposed to a malformed value or an I/O
+// error.
+func (d *decoder) truncated(err error) error {
+	if errors.Is(err, ErrTruncated) || errors.Is(err, ErrInvalidValue) {
+		return err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w at pos:%d: %v", ErrTruncated, d.offset(), err)
+	}
+	return err
+}
+
+// badPart handles a multipart entry that failed with err in a way that
+// leaves the next entry's position unknown. With skipBadParts the
+// failure is recorded and the parts decoded so far are returned as the
+// whole body; otherwise err is returned.
+func (d *decoder) badPart(parts []PDUPart, i int, err error) ([]PDUPart, error) {
+	err = d.truncated(err)
+	if !d.skipBadParts {
+		return nil, err
+	}
+	d.partErrors = append(d.partErrors, &PartError{Index: i, Err: err})
+	return parts, nil
+}
+
+// maxPartHeaderSlack is the largest number of bytes a multipart entry's
+// declared header length may fall short by in lenient mode.
+const maxPartHeaderSlack = 4
+
+// extendPartHeaders retries decoding the headers of a multipart entry
+// whose declared header length was too short, taking up to
+// maxPartHeaderSlack more bytes from the input. The extra bytes are
+// consumed once the headers decode cleanly. If no extension works the
+// original error is returned.
+func (d *decoder) extendPartHeaders(part *PDUPart, headerBuf []byte, origErr error) error {
+	for extra := 1; extra <= maxPartHeaderSlack; extra++ {
+		more, err := d.r.Peek(extra)
+		if err != nil {
+			break
+		}
+		buf := append(headerBuf[:len(headerBuf):len(headerBuf)], more...)
+		if part.decodeHeaders(buf, false) == nil {
+			d.r.Discard(extra)
+			return nil
+		}
+	}
+	return origErr
+}
+
+// decodeHeaders decodes the content type and headers of a multipart
+// entry into p. The headers must take exactly the declared header
+// length, len(headerBuf), unless skipRest is set, in which case
+// anything after the last header that decodes is ignored. The content
+// type must decode either way.
+func (p *PDUPart) decodeHeaders(headerBuf []byte, skipRest bool) error {
+	for k := range p.Header {
+		delete(p.Header, k)
+	}
+
+	tmpDecoder := newBytesDecoder(headerBuf)
+
+	// lengthErr reports running out of headerBuf as a header length
+	// that doesn't match the headers rather than a truncated PDU.
+	lengthErr := func(err error) error {
+		if errors.Is(err, ErrTruncated) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("mime part headers don't fit declared length %d: %v: %w", len(headerBuf), err, ErrInvalidValue)
+		}
+		return err
+	}
+
+	s, params, err := tmpDecoder.decodeContentTypeValue()
+	if err != nil {
+		return fmt.Errorf("decode content type for mime part err: %w", lengthErr(err))
+	}
+
+	p.ContentType = s
+	p.setContentTypeParams(params)
+	setUntypedParams(p.Header, tmpDecoder.untypedParams)
+
+	filename, headers, err := tmpDecoder.decodePartHeaders()
+	if err != nil && !skipRest {
+		return fmt.Errorf("parse mime part header err: %w", lengthErr(err))
+	}
+	if n := tmpDecoder.offset(); n != int64(len(headerBuf)) && !skipRest {
+		return fmt.Errorf("mime part headers used %d of declared length %d: %w", n, len(headerBuf), ErrInvalidValue)
+	}
+
+	p.FileName = filename
+	for k, v := range headers {
+		p.Header[k] = v
+	}
+	return nil
+}
+
 // WAP-209: section 7.1
 //
 //	Header = MMS-header | Application-header
-func (d *decoder) decodeHeader() (map[MMSField][]HeaderField, error) {
+func (d *decoder) decodeHeader(m *Message) error {
 	if d.err != nil {
-		return nil, d.err
+		return d.err
 	}
 
-	hdr := make(map[MMSField][]HeaderField)
+	hdr := m.Header
 
 OUTER:
 	for {
+		shifted, err := d.decodeCodePageShift()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			d.err = err
+			return err
+		}
+		if shifted {
+			continue
+		}
+
+		peekBuf, err := d.r.Peek(1)
+		if err != nil {
+			d.err = err
+			return err
+		}
+		if b := peekBuf[0]; b >= 32 && b < 127 {
+			// Application-header = Token-text Application-specific-value
+			name, err := d.decodeTextEnc()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			val, err := d.decodeTextEnc()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			if m.AppHeaders == nil {
+				m.AppHeaders = make(map[string]string)
+			}
+			m.AppHeaders[name] = val
+			continue
+		}
+
+		start := d.offset()
 		mmsFieldType, err := d.decodeFieldType()
 		if err == io.EOF {
 			break
 		} else if err != nil {
 			d.err = err
-			return nil, err
+			return err
 		}
 
 		if mmsFieldType == 0 {
@@ -163,11 +731,11 @@ OUTER:
 		}
 
 		switch mmsFieldType {
-		case Bcc, Cc, ResponseText, Subject, To:
+		case Bcc, Cc, ResponseText, RetrieveText, Subject, To:
 			str, err := d.decodeEncodedString()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hs := HeaderString(str)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
@@ -175,26 +743,28 @@ OUTER:
 			from, err := d.decodeFrom()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
-			hs := HeaderString(from)
-			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
-		case DeliveryReport, ReadReply, ReportAllowed:
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &from)
+		case DeliveryReport, ReadReply, ReportAllowed, AdaptationAllowed:
 			val, err := d.decodeBoolean()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hb := HeaderBool(val)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hb)
 		case ContentType:
-			val, _, err := d.decodeContentTypeValue()
+			val, params, err := d.decodeContentTypeValue()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
+			d.contentTypeParams = params
 			hs := HeaderString(val)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
+			m.FieldOrder = append(m.FieldOrder, mmsFieldType)
+			d.recordRawHeader(m, mmsFieldType, start)
 
 			// ContentType will be the last header
 			break OUTER
@@ -202,38 +772,46 @@ OUTER:
 			date, err := d.decodeDate()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hd := HeaderTime(date)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hd)
-		case DeliveryTime, Expiry:
+		case DeliveryTime, Expiry, ReplayChargingDeadline:
 			dt, err := d.decodeRelativeOrAbsoluteTime()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], dt)
-		case MessageSize:
+		case MessageSize, ReplayChargingSize:
 			size, err := d.decodeLongInt()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hu := HeaderUint(size)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hu)
+		case Start:
+			val, err := d.decodeIntegerValue()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			hu := HeaderUint(val)
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hu)
 		case MessageClass:
 			cls, err := d.decodeMessageClass()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hs := HeaderString(cls)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
-		case MessageID, ContentLocation, TransactionID:
+		case MessageID, ContentLocation, TransactionID, ReplayChargingID, ApplicID, ReplyApplicID, AuxApplicInfo, CancelID:
 			txt, err := d.decodeTextEnc()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hs := HeaderString(txt)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
@@ -241,14 +819,14 @@ OUTER:
 			typ, err := d.decodeMessageType()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &typ)
 		case MMSVersion:
 			version, err := d.decodeVersion()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hs := HeaderString(version)
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &hs)
@@ -256,45 +834,102 @@ OUTER:
 			priority, err := d.decodePriority()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &priority)
 		case ResponseStatus:
 			status, err := d.decodeResponseStatus()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &status)
 		case SenderVisibility:
 			vis, err := d.decodeSenderVisibility()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &vis)
 		case StatusField:
 			status, err := d.decodeStatus()
 			if err != nil {
 				d.err = err
-				return nil, err
+				return err
 			}
 			hdr[mmsFieldType] = append(hdr[mmsFieldType], &status)
 
+		case ReadStatus:
+			status, err := d.decodeReadStatus()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &status)
+
+		case CancelStatus:
+			status, err := d.decodeCancelStatus()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &status)
+
+		case ReplayCharging:
+			charging, err := d.decodeReplyCharging()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &charging)
+
 		case RetrieveStatus:
-			// XXXX don't populate
-			d.r.ReadByte()
+			status, err := d.decodeRetrieveStatus()
+			if err != nil {
+				d.err = err
+				return err
+			}
+			hdr[mmsFieldType] = append(hdr[mmsFieldType], &status)
 
 		default:
-			d.err = fmt.Errorf("unknown mms field type %s", mmsFieldType)
-			return nil, d.err
+			if !d.lenient {
+				d.err = fmt.Errorf("%w %s at pos:%d", ErrUnknownField, mmsFieldType, d.offset()-1)
+				return d.err
+			}
+			raw, enc, err := d.decodeUnknownValue()
+			if err != nil {
+				d.err = fmt.Errorf("skip unknown mms field type %s err: %w", mmsFieldType, err)
+				return d.err
+			}
+			m.UnknownHeaders = append(m.UnknownHeaders, UnknownHeader{
+				Field:    mmsFieldType,
+				Raw:      raw,
+				Encoding: enc,
+			})
+		}
+		if _, ok := hdr[mmsFieldType]; ok {
+			m.FieldOrder = append(m.FieldOrder, mmsFieldType)
 		}
+		d.recordRawHeader(m, mmsFieldType, start)
 	}
 
-	return hdr, nil
+	return nil
+}
+
+// recordRawHeader appends the input from start to the current offset
+// to the raw bytes of field f, if raw headers are being recorded.
+func (d *decoder) recordRawHeader(m *Message, f MMSField, start int64) {
+	if !d.rawHeaders {
+		return
+	}
+	if m.RawHeaders == nil {
+		m.RawHeaders = make(map[MMSField][]byte)
+	}
+	m.RawHeaders[f] = append(m.RawHeaders[f], d.sr.b[start:d.sr.off]...)
 }
 
-// decode a message multipart headers
+// decode a message multipart headers. On error the headers decoded
+// before the failing one are returned with it.
 func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 	resp := make(map[string]string)
 	var fileName string
@@ -305,7 +940,7 @@ func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 			break
 		}
 		if err != nil {
-			return "", nil, err
+			return fileName, resp, err
 		}
 		b := peekBuf[0]
 
@@ -314,9 +949,18 @@ func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 			header := PartHeaderField(b)
 			switch header {
 			case ContentLocationPartHeader, ContentIDPartHeader:
+				d.r.ReadByte()
 				txt, err := d.decodeTextEnc()
 				if err != nil {
-					return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
+				}
+
+				resp[header.String()] = txt
+			case ContentTransferEncodingPartHeader:
+				d.r.ReadByte()
+				txt, err := d.decodeTextEnc()
+				if err != nil {
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 				}
 
 				resp[header.String()] = txt
@@ -327,26 +971,22 @@ func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 				// Attachment = <Octet 129>
 				// Inline = <Octet 130>
 
+				d.r.ReadByte()
 				len, err := d.decodeValueLength()
 				if err != nil {
-					return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 				}
 
-				buf := make([]byte, int(len))
-				_, err = io.ReadFull(d.r, buf)
+				buf, err := d.readValue(len)
 				if err != nil {
-					return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 				}
 
-				rr := bytes.NewReader(buf)
-				tmpDecoder := decoder{
-					r:      bufio.NewReader(rr),
-					seeker: rr,
-				}
+				tmpDecoder := newBytesDecoder(buf)
 
 				peekBuf, err = tmpDecoder.r.Peek(1)
 				if err != nil {
-					return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 				}
 
 				b := peekBuf[0]
@@ -356,7 +996,7 @@ func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 				} else {
 					txt, err := tmpDecoder.decodeTextEnc()
 					if err != nil {
-						return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+						return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 					}
 
 					resp[header.String()] = txt
@@ -364,22 +1004,23 @@ func (d *decoder) decodePartHeaders() (string, map[string]string, error) {
 
 				params, err := tmpDecoder.decodeContentTypeParams()
 				if err != nil {
-					return "", nil, fmt.Errorf("parse %s header part err: %w", header, err)
+					return fileName, resp, fmt.Errorf("parse %s header part err: %w", header, err)
 				}
 
 				fileName = params[FilenameParam]
+				setDispositionParams(resp, params)
 
 			default:
-				return "", nil, fmt.Errorf("parse %s header part err: unknown header", header)
+				return fileName, resp, fmt.Errorf("parse %s header part err: unknown header: %w", header, ErrInvalidValue)
 			}
 		} else {
 			name, err := d.decodeTextEnc()
 			if err != nil {
-				return "", nil, err
+				return fileName, resp, err
 			}
 			val, err := d.decodeTextEnc()
 			if err != nil {
-				return "", nil, err
+				return fileName, resp, err
 			}
 
 			resp[name] = val
@@ -407,63 +1048,164 @@ func (d *decoder) decodeEncodedString() (string, error) {
 		if err != nil {
 			return "", err
 		}
-		buf := make([]byte, int(l))
-		_, err = io.ReadFull(d.r, buf)
+		buf, err := d.readValue(l)
 		if err != nil {
 			return "", err
 		}
 
 		if len(buf) < 1 {
-			return "", fmt.Errorf("invalid empty encoded string")
-		}
-
-		if buf[0] == 127 { // the any charset
-			return string(buf[1:]), nil
+			return "", fmt.Errorf("invalid empty encoded string: %w", ErrInvalidValue)
 		}
 
-		rr := bytes.NewReader(buf)
-		tmpDecoder := decoder{
-			r:      bufio.NewReader(rr),
-			seeker: rr,
-		}
-
-		if buf[0] > 32 {
-			return tmpDecoder.decodeTextEnc()
+		// Char-set = Well-known-charset | Token-text
+		// Well-known-charset = Any-charset | Integer-value
+		// Any-charset = <Octet 128>
+		switch {
+		case buf[0] == 127:
+			// Some encoders write a Quote in place of the Char-set to
+			// mean any charset. The text is taken as is.
+			return string(bytes.TrimSuffix(buf[1:], []byte{0})), nil
+		case buf[0] > 31 && buf[0] < 128:
+			// An Integer-value can't start with a TEXT octet, so this
+			// is a Token-text charset name or, from encoders that
+			// omit the Char-set, just the Text-string.
+			if i := bytes.IndexByte(buf, 0); i >= 0 && i < len(buf)-1 && isCharsetName(string(buf[:i])) {
+				return decodeEncodedText(string(buf[:i]), buf[i+1:]), nil
+			}
+			return newBytesDecoder(buf).decodeTextEnc()
 		}
 
-		contentTypeIdx, err := tmpDecoder.decodeLongInt()
+		tmpDecoder := newBytesDecoder(buf)
+		mib, err := tmpDecoder.decodeIntegerValue()
 		if err != nil {
 			return "", err
 		}
 
-		// TODO(psanford): need to handle the different content types here
-		_ = contentTypeIdx
-
-		text, err := io.ReadAll(rr)
+		text, err := io.ReadAll(tmpDecoder.r)
 		if err != nil {
 			return "", err
 		}
-		if len(text) <= 1 {
-			return "", nil
-		}
-		return string(text[:len(text)-1]), nil
 
-	} else {
-		text, err := d.r.ReadBytes(0)
-		if err != nil {
-			return "", err
-		}
-		if len(text) <= 1 {
-			return "", nil
-		}
-		return string(text[len(text)-1]), nil
+		// Any-charset and unknown charsets leave charset empty and the
+		// text as is.
+		return decodeEncodedText(charsets[int(mib)], text), nil
 	}
 
+	return d.decodeTextEnc()
+}
+
+// decodeEncodedText converts the Text-string of an Encoded-string-value
+// in charset to UTF-8, dropping its Quote and terminator. An empty
+// charset leaves the text unconverted.
+func decodeEncodedText(charset string, text []byte) string {
+	if len(text) > 1 && text[0] == 127 && text[1] > 127 {
+		text = text[1:]
+	}
+	text = bytes.TrimSuffix(text, []byte{0})
+	if charset == "" {
+		return string(text)
+	}
+	if isUTF16(charset) && len(text)%2 == 1 {
+		// The terminator of a 16 bit string may be one or two
+		// octets.
+		text = bytes.TrimSuffix(text, []byte{0})
+	}
+	return decodeCharset(charset, text)
+}
+
+// ctxErr returns the error of the decoder's context, or nil if it has
+// none or isn't done.
+func (d *decoder) ctxErr() error {
+	if d.ctx == nil {
+		return nil
+	}
+	return d.ctx.Err()
+}
+
+// newDecoder returns a decoder reading from r. size is the total
+// length of the input, or -1 if it is not known in advance.
+func newDecoder(r io.Reader, size int64) *decoder {
+	count := &countingReader{r: r}
+	br := bufio.NewReader(count)
+	return &decoder{
+		r:     br,
+		br:    br,
+		count: count,
+		size:  size,
+	}
 }
 
+// newBytesDecoder returns a decoder reading b. Nested values are
+// decoded from subslices of b rather than copies.
+func newBytesDecoder(b []byte) *decoder {
+	sr := &sliceReader{b: b}
+	return &decoder{
+		r:    sr,
+		sr:   sr,
+		size: int64(len(b)),
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// offset returns the position of the next unread byte. The bufio
+// reader reads ahead of the underlying reader, so anything still
+// buffered has not been consumed yet.
 func (d *decoder) offset() int64 {
-	i, _ := d.seeker.Seek(0, io.SeekCurrent)
-	return i
+	if d.sr != nil {
+		return int64(d.sr.off)
+	}
+	return d.count.n - int64(d.br.Buffered())
+}
+
+// decodeCodePageShift consumes a header code page shift sequence if
+// one is next, reporting whether it did.
+//
+//	Shift-sequence = Shift-delimiter Page-identity
+//	Shift-delimiter = <Octet 127>
+//	Page-identity = <Any octet 1-255>
+//
+// Some encoders set the high bit on the shift delimiter like a field
+// code, so 0xff is accepted as well. All MMS fields live on the default
+// page 1; fields on any other page can't be decoded. WSP short-cut
+// shifts (octets 1-31) are not accepted since they can't be told apart
+// from a corrupt field code.
+func (d *decoder) decodeCodePageShift() (bool, error) {
+	peekBytes, err := d.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	b := peekBytes[0]
+
+	if b != 0x7f && b != 0xff {
+		return false, nil
+	}
+
+	pos := d.offset()
+	d.r.ReadByte()
+	page, err := d.r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if page == 0 {
+		return false, fmt.Errorf("invalid code page shift at pos:%d: %w", pos, ErrInvalidValue)
+	}
+
+	if page != 1 {
+		return false, fmt.Errorf("unsupported header code page %d at pos:%d: %w", page, d.offset(), ErrInvalidValue)
+	}
+
+	return true, nil
 }
 
 func (d *decoder) decodeFieldType() (MMSField, error) {
@@ -473,7 +1215,7 @@ func (d *decoder) decodeFieldType() (MMSField, error) {
 	}
 	b := peekBytes[0]
 	if b&0x80 != 0x80 {
-		return 0, fmt.Errorf("invalid short int at pos:%d, value: 0x%x", d.offset()-1, b)
+		return 0, fmt.Errorf("%w at pos:%d, value: 0x%x", ErrInvalidShortInt, d.offset(), b)
 	}
 	f := b & 0x7f
 	d.r.ReadByte()
@@ -493,10 +1235,10 @@ func (d *decoder) decodeBoolean() (bool, error) {
 		return false, nil
 	}
 
-	return false, fmt.Errorf("Invalid boolean value at pos:%d, value: 0x%x", d.offset()-1, b)
+	return false, fmt.Errorf("%w at pos:%d, value: 0x%x", ErrInvalidBoolean, d.offset()-1, b)
 }
 
-func (d *decoder) decodeLongInt() (uint32, error) {
+func (d *decoder) decodeLongInt() (uint64, error) {
 	// 	Long-integer = Short-length Multi-octet-integer
 	// ; The Short-length indicates the length of the Multi-octet-integer
 	// Multi-octet-integer = 1*30 OCTET
@@ -507,33 +1249,48 @@ func (d *decoder) decodeLongInt() (uint32, error) {
 		return 0, err
 	}
 	if shortLen > 30 {
-		return 0, fmt.Errorf("invalid long int at pos:%d, shortLen: 0x%x", d.offset()-1, shortLen)
+		return 0, fmt.Errorf("invalid long int at pos:%d, shortLen: 0x%x: %w", d.offset()-1, shortLen, ErrInvalidValue)
 	}
 
 	if shortLen > 8 {
-		return 0, fmt.Errorf("unsupported long int at pos:%d, byte size: %d", d.offset()-1, shortLen)
+		return 0, fmt.Errorf("unsupported long int at pos:%d, byte size: %d: %w", d.offset()-1, shortLen, ErrInvalidValue)
 	}
 
-	var u uint32
+	var u uint64
 	for i := 0; i < int(shortLen); i++ {
 		b, err := d.r.ReadByte()
 		if err != nil {
 			return 0, err
 		}
 		u <<= 8
-		u |= uint32(b)
+		u |= uint64(b)
 	}
 
 	return u, nil
 }
 
+// decodeIntegerValue decodes an Integer-value.
+//
+//	Integer-Value = Short-integer | Long-integer
+func (d *decoder) decodeIntegerValue() (uint64, error) {
+	peekBuf, err := d.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	if peekBuf[0] > 127 {
+		b, err := d.decodeShortInt()
+		return uint64(b), err
+	}
+	return d.decodeLongInt()
+}
+
 func (d *decoder) decodeShortInt() (byte, error) {
 	b, err := d.r.ReadByte()
 	if err != nil {
 		return 0, err
 	}
 	if b&0x80 != 0x80 {
-		return 0, fmt.Errorf("invalid short int at pos:%d, value: 0x%x", d.offset()-1, b)
+		return 0, fmt.Errorf("%w at pos:%d, value: 0x%x", ErrInvalidShortInt, d.offset()-1, b)
 	}
 	return b & 0x7f, nil
 }
@@ -554,16 +1311,19 @@ func (d *decoder) decodeMessageClass() (string, error) {
 	}
 	b := peakbuf[0]
 
-	if b < 127 {
+	if b < 128 {
+		// Token-text = Token End-of-string
 		text, err := d.r.ReadBytes(0)
 		if err != nil {
 			return "", err
 		}
-		if len(text) < 2 {
-			return "", nil
+		text = text[:len(text)-1]
+		// Tolerate a Text-string Quote written ahead of the token.
+		if len(text) > 0 && text[0] == 127 {
+			text = text[1:]
 		}
 
-		return string(text[:len(text)-1]), nil
+		return string(text), nil
 	}
 
 	d.r.ReadByte()
@@ -597,14 +1357,53 @@ func (d *decoder) decodeRelativeOrAbsoluteTime() (*HeaderRelativeOrAbsoluteTime,
 	// Delivery-time-value = Value-length (Absolute-token Date-value | Relative-token Delta-seconds-value)
 	// Absolute-token = <Octet 128>
 	// Relative-token = <Octet 129>
-
-	d.decodeValueLength()
+	//
+	// Some non-conforming MMSCs send a bare Date-value (a Long-integer)
+	// without the Value-length and token. In lenient mode a value is
+	// taken as bare when its first octet is a Long-integer length of 1
+	// to 8 with that many octets following, unless it reads as a
+	// conforming value: a token then an integer that exactly fills the
+	// Value-length. Timestamps from 2038 on start with 0x80 or 0x81,
+	// so the token alone can't tell the forms apart.
 
 	const (
 		absolute = 128
 		relative = 129
 	)
 
+	bare := func() bool {
+		buf, err := d.r.Peek(1)
+		if err != nil {
+			return false
+		}
+		n := int(buf[0])
+		if n < 1 || n > 8 {
+			return false
+		}
+		buf, err = d.r.Peek(1 + n)
+		if err != nil || n < 2 || (buf[1] != absolute && buf[1] != relative) {
+			return err == nil
+		}
+		if n == 2 && buf[2] > 127 {
+			// token then Short-integer
+			return false
+		}
+		return int(buf[2]) != n-2
+	}
+
+	if d.lenient && bare() {
+		val, err := d.decodeLongInt()
+		if err != nil {
+			return nil, err
+		}
+		ts := time.Unix(int64(val), 0)
+		return &HeaderRelativeOrAbsoluteTime{Absolute: &ts}, nil
+	}
+
+	if _, err := d.decodeValueLength(); err != nil {
+		return nil, err
+	}
+
 	mode, err := d.r.ReadByte()
 	if err != nil {
 		return nil, err
@@ -624,7 +1423,7 @@ func (d *decoder) decodeRelativeOrAbsoluteTime() (*HeaderRelativeOrAbsoluteTime,
 		d := time.Duration(int64(val)) * time.Second
 		result.Relative = &d
 	default:
-		return nil, fmt.Errorf("invalid delivery_time mode: 0x%x", mode)
+		return nil, fmt.Errorf("invalid delivery_time mode: 0x%x: %w", mode, ErrInvalidValue)
 	}
 
 	return &result, nil
@@ -647,6 +1446,8 @@ func (d *decoder) decodeContentTypeValue() (string, map[WellKnownParam]string, e
 	// Constrained-encoding = Extension-Media | Short-integer
 	// Short-integer = u8 > 127
 
+	d.untypedParams = nil
+
 	peakbuf, err := d.r.Peek(1)
 	if err != nil {
 		return "", nil, err
@@ -660,26 +1461,30 @@ func (d *decoder) decodeContentTypeValue() (string, map[WellKnownParam]string, e
 		if err != nil {
 			return "", nil, err
 		}
-		buf := make([]byte, int(l))
-		_, err = io.ReadFull(d.r, buf)
+		buf, err := d.readValue(l)
 		if err != nil {
 			return "", nil, err
 		}
 
-		rr := bytes.NewReader(buf)
-		tmpDecoder := decoder{
-			r:      bufio.NewReader(rr),
-			seeker: rr,
-		}
-		contentType, err := tmpDecoder.decodeConstrainedMedia()
-		if err != nil {
-			return "", nil, err
+		tmpDecoder := newBytesDecoder(buf)
+
+		var contentType string
+		if missingMediaType(buf) {
+			// Treat the whole value as parameters rather than
+			// misreading the first parameter code as a media type.
+			contentType = "application/octet-stream"
+		} else {
+			contentType, err = tmpDecoder.decodeConstrainedMedia()
+			if err != nil {
+				return "", nil, err
+			}
 		}
 
 		params, err := tmpDecoder.decodeContentTypeParams()
 		if err != nil {
 			return "", nil, fmt.Errorf("decode content type params err: %w", err)
 		}
+		d.untypedParams = tmpDecoder.untypedParams
 
 		return contentType, params, nil
 
@@ -690,8 +1495,35 @@ func (d *decoder) decodeContentTypeValue() (string, map[WellKnownParam]string, e
 	}
 }
 
+// missingMediaType reports whether a general form content type value
+// appears to start directly with a parameter instead of a media type.
+// Short-integer media codes overlap the well-known parameter codes, so
+// this only matches a text-valued parameter immediately followed by a
+// terminated text string, which no short-integer media type would be.
+func missingMediaType(buf []byte) bool {
+	if len(buf) < 2 {
+		return false
+	}
+
+	switch WellKnownParam(buf[0]) {
+	case TypeParam, CtMrTypeParam, StartParam, DepStartParam, NameParam, DepNameParam:
+	default:
+		return false
+	}
+
+	if buf[1] < 32 || buf[1] > 126 {
+		return false
+	}
+
+	return bytes.IndexByte(buf[1:], 0) >= 0
+}
+
+// decodeContentTypeParams decodes the parameters that follow a media
+// type or disposition. Untyped parameters are recorded in
+// d.untypedParams.
 func (d *decoder) decodeContentTypeParams() (map[WellKnownParam]string, error) {
 	out := make(map[WellKnownParam]string)
+	d.untypedParams = nil
 	for {
 		b, err := d.r.ReadByte()
 		if err == io.EOF {
@@ -742,7 +1574,7 @@ func (d *decoder) decodeContentTypeParams() (map[WellKnownParam]string, error) {
 				return nil, err
 			}
 			b := peakbuf[0]
-			if b < 127 {
+			if b > 31 && b < 127 {
 				// Extension-Media = *TEXT End-of-string
 				// *TEXT = byte array where each byte > 31 < 127
 				text, err := d.r.ReadBytes(0)
@@ -752,14 +1584,27 @@ func (d *decoder) decodeContentTypeParams() (map[WellKnownParam]string, error) {
 				if len(text) <= 1 {
 					continue
 				}
-				out[CharsetParam] = string(text[len(text)-1])
+				out[CharsetParam] = string(text[:len(text)-1])
 			} else {
-				b, err = d.decodeShortInt()
+				// Well-known-charset = Any-charset | Integer-value
+				// Any-charset = <Octet 128>
+				// Integer-value = Short-integer | Long-integer
+				// The integer is the IANA MIBEnum of the charset.
+				var mib uint64
+				if b <= 30 {
+					mib, err = d.decodeLongInt()
+				} else {
+					var short byte
+					short, err = d.decodeShortInt()
+					mib = uint64(short)
+				}
 				if err != nil {
 					return nil, err
 				}
-				if int(b) < len(contentTypes) {
-					out[CharsetParam] = contentTypes[b]
+				if name, ok := CharsetName(int(mib)); ok {
+					out[CharsetParam] = name
+				} else {
+					out[CharsetParam] = strconv.FormatUint(mib, 10)
 				}
 			}
 
@@ -769,12 +1614,198 @@ func (d *decoder) decodeContentTypeParams() (map[WellKnownParam]string, error) {
 				return nil, err
 			}
 			out[NameParam] = name
+		case FilenameParam, DepFilenameParam:
+			name, err := d.decodeTextEnc()
+			if err != nil {
+				return nil, err
+			}
+			out[FilenameParam] = name
+		case CreationDateParam, ModificationDateParam, ReadDateParam:
+			date, err := d.decodeDate()
+			if err != nil {
+				return nil, err
+			}
+			out[param] = date.UTC().Format(time.RFC3339)
+		case QParam:
+			q, err := d.decodeQValue()
+			if err != nil {
+				return nil, err
+			}
+			out[QParam] = q
+		case LevelParam:
+			// Level = Version-value
+			// Version-value = Short-integer | Text-string
+			peakbuf, err := d.r.Peek(1)
+			if err != nil {
+				return nil, err
+			}
+			var level string
+			if peakbuf[0] > 127 {
+				level, err = d.decodeVersion()
+			} else {
+				level, err = d.decodeTextEnc()
+			}
+			if err != nil {
+				return nil, err
+			}
+			out[LevelParam] = level
+		case DifferencesParam:
+			// Differences = Field-name
+			// Field-name = Token-text | Well-known-field-name
+			peakbuf, err := d.r.Peek(1)
+			if err != nil {
+				return nil, err
+			}
+			if peakbuf[0] > 127 {
+				field, err := d.decodeShortInt()
+				if err != nil {
+					return nil, err
+				}
+				out[DifferencesParam] = strconv.Itoa(int(field))
+			} else {
+				text, err := d.decodeTextEnc()
+				if err != nil {
+					return nil, err
+				}
+				out[DifferencesParam] = text
+			}
+		case PaddingParam, SecParam:
+			// Padding = Short-integer
+			// SEC = Short-integer
+			v, err := d.decodeShortInt()
+			if err != nil {
+				return nil, err
+			}
+			out[param] = strconv.Itoa(int(v))
+		case MaxAgeParam, SizeParam:
+			// Max-Age = Delta-seconds-value
+			// Size = Integer-value
+			v, err := d.decodeIntegerValue()
+			if err != nil {
+				return nil, err
+			}
+			out[param] = strconv.FormatUint(v, 10)
+		case SecureParam:
+			// Secure = No-value
+			if _, err := d.r.ReadByte(); err != nil {
+				return nil, err
+			}
+			out[SecureParam] = ""
+		case StartInfoParam, DepStartInfoParam, CommentParam, DepCommentParam,
+			DomainParam, DepDomainParam, PathParam, DepPathParam, MacParam:
+			// The deprecated encodings are Text-strings, their
+			// replacements and MAC are Text-values.
+			text, err := d.decodeTextEnc()
+			if err != nil {
+				return nil, err
+			}
+			out[currentParam(param)] = text
+		default:
+			if b < 128 {
+				// Untyped-parameter = Token-text Untyped-value
+				rest, err := d.r.ReadBytes(0)
+				if err != nil {
+					return nil, err
+				}
+				v, err := d.decodeUntypedValue()
+				if err != nil {
+					return nil, err
+				}
+				if d.untypedParams == nil {
+					d.untypedParams = make(map[string]string)
+				}
+				d.untypedParams[string(b)+string(rest[:len(rest)-1])] = v
+				continue
+			}
+			// A parameter code this decoder doesn't know. Its
+			// encoding isn't known either, so decode it as an
+			// Untyped-value to stay in step with the parameters
+			// that follow.
+			v, err := d.decodeUntypedValue()
+			if err != nil {
+				return nil, fmt.Errorf("parameter 0x%x err: %w", b, err)
+			}
+			out[param] = v
 		}
 	}
 
 	return out, nil
 }
 
+// decodeUntypedValue decodes the value of an untyped or unknown
+// parameter. Integers are returned in decimal.
+//
+//	Untyped-value = Integer-value | Text-value
+//	Text-value = No-value | Token-text | Quoted-string
+func (d *decoder) decodeUntypedValue() (string, error) {
+	peekBuf, err := d.r.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	switch b := peekBuf[0]; {
+	case b == 0:
+		// No-value
+		d.r.ReadByte()
+		return "", nil
+	case b <= 30, b > 127:
+		v, err := d.decodeIntegerValue()
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(v, 10), nil
+	default:
+		return d.decodeTextEnc()
+	}
+}
+
+// currentParam maps a deprecated parameter code to the code that
+// replaced it in later WSP versions.
+func currentParam(p WellKnownParam) WellKnownParam {
+	switch p {
+	case DepStartInfoParam:
+		return StartInfoParam
+	case DepCommentParam:
+		return CommentParam
+	case DepDomainParam:
+		return DomainParam
+	case DepPathParam:
+		return PathParam
+	}
+	return p
+}
+
+// decodeQValue decodes a Q-value into its decimal string form, e.g.
+// "0.7" or "0.125".
+//
+//	Q-value = 1*2 OCTET
+//	; The encoding is the same as in Uintvar-integer, but with restricted
+//	; size. When quality factor 0 and quality factors with one or two
+//	; decimal digits are encoded, they shall be multiplied by 100 and
+//	; incremented by one, so that they encode as a one-octet value in
+//	; range 1-100, ie, 0.1 is encoded as 11 (0x0B) and 0.99 encoded as
+//	; 100 (0x64). Three decimal quality factors shall be multiplied with
+//	; 1000 and incremented by 100, and the result shall be encoded as a
+//	; one-octet or two-octet uintvar, eg, 0.333 shall be encoded as 0x83
+//	; 0x31.
+func (d *decoder) decodeQValue() (string, error) {
+	v, err := d.decodeVarUint()
+	if err != nil {
+		return "", err
+	}
+
+	var q float64
+	switch {
+	case v >= 1 && v <= 100:
+		q = float64(v-1) / 100
+	case v >= 101 && v <= 1099:
+		q = float64(v-100) / 1000
+	default:
+		return "", fmt.Errorf("invalid q-value %d at pos:%d: %w", v, d.offset(), ErrInvalidValue)
+	}
+
+	return strconv.FormatFloat(q, 'f', -1, 64), nil
+}
+
 func (d *decoder) decodeValueLength() (uint32, error) {
 	// 8.4.2.2 Length
 	// The following rules are used to encode length indicators.
@@ -792,7 +1823,7 @@ func (d *decoder) decodeValueLength() (uint32, error) {
 	} else if b == 31 {
 		return d.decodeVarUint()
 	} else {
-		return 0, fmt.Errorf("invalid value length at pos:%d value 0x%x", d.offset()-1, b)
+		return 0, fmt.Errorf("invalid value length at pos:%d value 0x%x: %w", d.offset()-1, b, ErrInvalidValue)
 	}
 }
 
@@ -813,46 +1844,86 @@ func (d *decoder) decodeVarUint() (uint32, error) {
 		more = b&0x80 == 0x80
 	}
 	if more {
-		return 0, fmt.Errorf("invalid var uint")
+		return 0, fmt.Errorf("invalid var uint at pos:%d: %w", d.offset(), ErrInvalidValue)
 	}
 	return result, nil
 }
 
-func (d *decoder) decodeFrom() (string, error) {
+// remaining returns the number of undecoded bytes left in the input,
+// or math.MaxInt32 if the input length is unknown.
+func (d *decoder) remaining() int {
+	if d.size < 0 {
+		return math.MaxInt32
+	}
+	return int(d.size - d.offset())
+}
+
+// limit returns the largest value length the decoder accepts.
+func (d *decoder) limit() int {
+	if d.maxLength > 0 {
+		return d.maxLength
+	}
+	return DefaultMaxLength
+}
+
+// checkLength validates a declared length against the decoder's limit
+// and the remaining input before anything is allocated for it.
+func (d *decoder) checkLength(n uint64, what string) error {
+	if n > uint64(d.limit()) {
+		return fmt.Errorf("%s length %d exceeds %d at pos:%d: %w", what, n, d.limit(), d.offset(), ErrTooLarge)
+	}
+	if rem := d.remaining(); n > uint64(rem) {
+		return fmt.Errorf("%s length %d exceeds remaining %d bytes at pos:%d: %w", what, n, rem, d.offset(), ErrTruncated)
+	}
+	return nil
+}
+
+// readValue reads a value of the declared length n. For in-memory
+// input the value aliases the input, so it must be copied before it is
+// retained.
+func (d *decoder) readValue(n uint32) ([]byte, error) {
+	if err := d.checkLength(uint64(n), "value"); err != nil {
+		return nil, err
+	}
+	if d.sr != nil {
+		return d.sr.next(int(n))
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (d *decoder) decodeFrom() (HeaderFrom, error) {
 	// From-value = Value-length (Address-present-token Encoded-string-value | Insert-address-token )
 	// Address-present-token = <Octet 128>
 	// Insert-address-token = <Octet 129>
 	l, err := d.decodeValueLength()
 	if err != nil {
-		return "", err
+		return HeaderFrom{}, err
 	}
 	if l < 1 {
-		return "", fmt.Errorf("invalid from field")
+		return HeaderFrom{}, fmt.Errorf("invalid from field at pos:%d: %w", d.offset(), ErrInvalidValue)
 	}
 
-	buf := make([]byte, int(l))
-
-	_, err = io.ReadFull(d.r, buf)
+	buf, err := d.readValue(l)
 	if err != nil {
-		return "", err
+		return HeaderFrom{}, err
 	}
 
 	b := buf[0]
 
 	switch b {
 	case 128:
-		rr := bytes.NewReader(buf[1:])
-
-		tmpDecoder := decoder{
-			r:      bufio.NewReader(rr),
-			seeker: rr,
-		}
-		return tmpDecoder.decodeEncodedString()
+		tmpDecoder := newBytesDecoder(buf[1:])
+		addr, err := tmpDecoder.decodeEncodedString()
+		return HeaderFrom{Address: addr}, err
 	case 129:
-		return "<insert-address-token>", nil
+		return HeaderFrom{Insert: true}, nil
 	}
 
-	return "", fmt.Errorf("invalid from field token state: 0x%x", b)
+	return HeaderFrom{}, fmt.Errorf("invalid from field token state: 0x%x at pos:%d: %w", b, d.offset(), ErrInvalidValue)
 }
 
 func (d *decoder) decodeTextEnc() (string, error) {
@@ -867,8 +1938,8 @@ func (d *decoder) decodeTextEnc() (string, error) {
 
 	b := peekbuf[0]
 
-	if b > 127 {
-		// hasQuote
+	if b == 127 {
+		// Quote
 		d.r.ReadByte()
 	}
 
@@ -899,14 +1970,14 @@ func (d *decoder) decodeConstrainedMedia() (string, error) {
 		if len(text) <= 1 {
 			return "", nil
 		}
-		return string(text[len(text)-1]), nil
+		return string(text[:len(text)-1]), nil
 	} else {
 		b, err = d.decodeShortInt()
 		if err != nil {
 			return "", err
 		}
 		if int(b) >= len(contentTypes) {
-			return "", fmt.Errorf("unknown short content type %d", b)
+			return "", fmt.Errorf("unknown short content type %d: %w", b, ErrInvalidValue)
 		}
 		return contentTypes[b], nil
 	}
@@ -922,6 +1993,10 @@ func (d *decoder) decodeMessageType() (HeaderMessageType, error) {
 	// m-retrieve-conf = <Octet 132>
 	// m-acknowledge-ind = <Octet 133>
 	// m-delivery-ind = <Octet 134>
+	// m-read-rec-ind = <Octet 135>
+	// m-read-orig-ind = <Octet 136>
+	// ...
+	// m-cancel-conf = <Octet 151>
 	// Unknown message types will be discarded.
 
 	b, err := d.r.ReadByte()
@@ -929,7 +2004,7 @@ func (d *decoder) decodeMessageType() (HeaderMessageType, error) {
 		return 0, err
 	}
 
-	if b < 128 || b > 134 {
+	if b < 128 || b > 151 {
 		return UnknownMessageType, nil
 	}
 
@@ -987,6 +2062,38 @@ func (d *decoder) decodeStatus() (HeaderStatus, error) {
 	return HeaderStatus(b), nil
 }
 
+func (d *decoder) decodeReadStatus() (HeaderReadStatus, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return HeaderReadStatus(b), nil
+}
+
+func (d *decoder) decodeReplyCharging() (HeaderReplyCharging, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return HeaderReplyCharging(b), nil
+}
+
+func (d *decoder) decodeRetrieveStatus() (HeaderRetrieveStatus, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return HeaderRetrieveStatus(b), nil
+}
+
+func (d *decoder) decodeCancelStatus() (HeaderCancelStatus, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return HeaderCancelStatus(b), nil
+}
+
 type MMSField int
 
 const (
@@ -1016,80 +2123,82 @@ const (
 	TransactionID    MMSField = 0x18
 
 	RetrieveStatus         MMSField = 0x19
-	RetrieveText           MMSField = 0x20
-	ReadStatus             MMSField = 0x21
-	ReplayCharging         MMSField = 0x22
-	ReplayChargingDeadline MMSField = 0x23
-	ReplayChargingID       MMSField = 0x24
-	ReplayChargingSize     MMSField = 0x25
+	RetrieveText           MMSField = 0x1a
+	ReadStatus             MMSField = 0x1b
+	ReplayCharging         MMSField = 0x1c
+	ReplayChargingDeadline MMSField = 0x1d
+	ReplayChargingID       MMSField = 0x1e
+	ReplayChargingSize     MMSField = 0x1f
+
+	Start             MMSField = 0x2f
+	ApplicID          MMSField = 0x37
+	ReplyApplicID     MMSField = 0x38
+	AuxApplicInfo     MMSField = 0x39
+	AdaptationAllowed MMSField = 0x3c
+	CancelID          MMSField = 0x3e
+	CancelStatus      MMSField = 0x3f
 )
 
-func (f MMSField) String() string {
-	switch f {
-	case Bcc:
-		return "Bcc"
-	case Cc:
-		return "Cc"
-	case ContentLocation:
-		return "Content-Location"
-	case ContentType:
-		return "Content-Type"
-	case Date:
-		return "Date"
-	case DeliveryReport:
-		return "Delivery-Report"
-	case DeliveryTime:
-		return "Delivery-Time"
-	case Expiry:
-		return "Expiry"
-	case From:
-		return "From"
-	case MessageClass:
-		return "Message-Class"
-	case MessageID:
-		return "Message-ID"
-	case MessageType:
-		return "Message-Type"
-	case MMSVersion:
-		return "MMS-Version"
-	case MessageSize:
-		return "Message-Size"
-	case Priority:
-		return "Priority"
-	case ReadReply:
-		return "Read-Reply"
-	case ReportAllowed:
-		return "Report-Allowed"
-	case ResponseStatus:
-		return "Response-Status"
-	case ResponseText:
-		return "Response-Text"
-	case SenderVisibility:
-		return "Sender-Visibility"
-	case StatusField:
-		return "Status"
-	case Subject:
-		return "Subject"
-	case To:
-		return "To"
-	case TransactionID:
-		return "Transaction-ID"
-	case RetrieveStatus:
-		return "Retrieve-Status"
-	case RetrieveText:
-		return "Retrieve-Text"
-	case ReadStatus:
-		return "Read-Status"
-	case ReplayCharging:
-		return "Replay-Charging"
-	case ReplayChargingDeadline:
-		return "Replay-Charging-Deadline"
-	case ReplayChargingID:
-		return "Replay-Charging-ID"
-	case ReplayChargingSize:
-		return "Replay-Charging-Size"
+// MMSFieldNames maps each known MMSField to its header name.
+var MMSFieldNames = map[MMSField]string{
+	Bcc:                    "Bcc",
+	Cc:                     "Cc",
+	ContentLocation:        "Content-Location",
+	ContentType:            "Content-Type",
+	Date:                   "Date",
+	DeliveryReport:         "Delivery-Report",
+	DeliveryTime:           "Delivery-Time",
+	Expiry:                 "Expiry",
+	From:                   "From",
+	MessageClass:           "Message-Class",
+	MessageID:              "Message-ID",
+	MessageType:            "Message-Type",
+	MMSVersion:             "MMS-Version",
+	MessageSize:            "Message-Size",
+	Priority:               "Priority",
+	ReadReply:              "Read-Reply",
+	ReportAllowed:          "Report-Allowed",
+	ResponseStatus:         "Response-Status",
+	ResponseText:           "Response-Text",
+	SenderVisibility:       "Sender-Visibility",
+	StatusField:            "Status",
+	Subject:                "Subject",
+	To:                     "To",
+	TransactionID:          "Transaction-ID",
+	RetrieveStatus:         "Retrieve-Status",
+	RetrieveText:           "Retrieve-Text",
+	ReadStatus:             "Read-Status",
+	ReplayCharging:         "Replay-Charging",
+	ReplayChargingDeadline: "Replay-Charging-Deadline",
+	ReplayChargingID:       "Replay-Charging-ID",
+	ReplayChargingSize:     "Replay-Charging-Size",
+	Start:                  "Start",
+	ApplicID:               "Applic-ID",
+	ReplyApplicID:          "Reply-Applic-ID",
+	AuxApplicInfo:          "Aux-Applic-Info",
+	AdaptationAllowed:      "Adaptation-Allowed",
+	CancelID:               "Cancel-ID",
+	CancelStatus:           "Cancel-Status",
+}
 
-	default:
-		return fmt.Sprintf("UnknownMMSField<%d>", f)
+var mmsFieldsByName = func() map[string]MMSField {
+	m := make(map[string]MMSField, len(MMSFieldNames))
+	for f, name := range MMSFieldNames {
+		m[strings.ToLower(name)] = f
+	}
+	return m
+}()
+
+// MMSFieldByName returns the MMSField with the given header name. The
+// lookup is case-insensitive.
+func MMSFieldByName(name string) (MMSField, bool) {
+	f, ok := mmsFieldsByName[strings.ToLower(name)]
+	return f, ok
+}
+
+func (f MMSField) String() string {
+	if name, ok := MMSFieldNames[f]; ok {
+		return name
 	}
+	return fmt.Sprintf("UnknownMMSField<%d>", f)
 }
@@ -1,9 +1,16 @@
 package mms
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestMms(t *testing.T) {
@@ -17,5 +24,929 @@ func TestMms(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	fmt.Printf("msg: %+v\n", msg)
+	t.Logf("msg:\n%s", msg)
+}
+
+func TestLeadingMessageType(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // X-Mms-Message-Type: m-retrieve-conf
+		0x98, 't', 'x', 0x00, // X-Mms-Transaction-Id: tx
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x84, 0xa3, // Content-Type: application/vnd.wap.multipart.mixed
+		0x00, // no parts
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typ, ok := msg.Header[MessageType][0].(*HeaderMessageType)
+	if !ok || *typ != MRetrieveConf {
+		t.Fatalf("unexpected message type %v", msg.Header[MessageType])
+	}
+	if got := msg.Header[TransactionID][0].String(); got != "tx" {
+		t.Fatalf("transaction id got %q", got)
+	}
+
+	// error positions are relative to the very first byte
+	checks := []struct {
+		packet []byte
+		pos    string
+	}{
+		{[]byte{0x0c, 0x84}, "pos:0,"},
+		{[]byte{0x8c, 0x84, 0x0d, 0x92}, "pos:2,"},
+		{[]byte{0x8c, 0x84, 0x86, 0x01}, "pos:3,"},
+	}
+	for _, check := range checks {
+		_, err := Unmarshal(check.packet)
+		if err == nil {
+			t.Fatalf("expected error for %x", check.packet)
+		}
+		if !strings.Contains(err.Error(), check.pos) {
+			t.Errorf("error for %x got %q, want %s", check.packet, err, check.pos)
+		}
+	}
+}
+
+func TestContentTypeMissingMediaType(t *testing.T) {
+	// the media type was dropped, leaving only a Type parameter
+	val := append([]byte{0x12, 0x89}, "application/smil\x00"...)
+
+	dec := newBytesDecoder(val)
+
+	ct, params, err := dec.decodeContentTypeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct != "application/octet-stream" {
+		t.Errorf("content type got %q", ct)
+	}
+	if params[TypeParam] != "application/smil" {
+		t.Errorf("type param got %q", params[TypeParam])
+	}
+
+	// a real short-integer media type followed by parameters is unaffected
+	val = []byte{0x03, 0x83, 0x81, 0xea}
+	dec = newBytesDecoder(val)
+	ct, _, err = dec.decodeContentTypeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct != "text/plain" {
+		t.Errorf("content type got %q", ct)
+	}
+}
+
+func TestHeaderCodePageShift(t *testing.T) {
+	checks := [][]byte{
+		{0x8c, 0x84, 0xff, 0x01, 0x8d, 0x92},
+		{0x8c, 0x84, 0x7f, 0x01, 0x8d, 0x92},
+	}
+	for _, packet := range checks {
+		msg, err := Unmarshal(packet)
+		if err != nil {
+			t.Fatalf("%x: %s", packet, err)
+		}
+		if got := msg.Header[MMSVersion][0].String(); got != "1.2" {
+			t.Errorf("%x: version got %q", packet, got)
+		}
+	}
+
+	_, err := Unmarshal([]byte{0x8c, 0x84, 0xff, 0x02, 0x8d, 0x92})
+	if err == nil || !strings.Contains(err.Error(), "code page 2") {
+		t.Fatalf("expected unsupported code page error, got %v", err)
+	}
+}
+
+func TestBareExpiry(t *testing.T) {
+	bare := []byte{
+		0x8c, 0x82, // m-notification-ind
+		0x88, 0x04, 0x65, 0x53, 0xf1, 0x00, // X-Mms-Expiry: bare long-integer
+		0x8d, 0x92, // 1.2
+	}
+	if _, err := Unmarshal(bare); err == nil {
+		t.Fatal("strict decode accepted a bare expiry")
+	}
+	msg, err := UnmarshalLenient(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiry := msg.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime)
+	if expiry.Absolute == nil || expiry.Absolute.Unix() != 0x6553f100 {
+		t.Fatalf("unexpected expiry %+v", expiry)
+	}
+	if got := msg.Header[MMSVersion][0].String(); got != "1.2" {
+		t.Fatalf("version got %q", got)
+	}
+
+	for _, decode := range []func([]byte) (*Message, error){Unmarshal, UnmarshalLenient} {
+		msg, err = decode([]byte{
+			0x8c, 0x82, // m-notification-ind
+			0x88, 0x06, 0x80, 0x04, 0x65, 0x53, 0xf1, 0x00, // X-Mms-Expiry: absolute
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expiry = msg.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime)
+		if expiry.Absolute == nil || expiry.Absolute.Unix() != 0x6553f100 {
+			t.Fatalf("unexpected expiry %+v", expiry)
+		}
+	}
+}
+
+func TestBareExpiryAfter2038(t *testing.T) {
+	for _, ts := range []uint32{0x80000000, 0x81234567} {
+		packet := []byte{
+			0x8c, 0x82, // m-notification-ind
+			0x88, 0x04, byte(ts >> 24), byte(ts >> 16), byte(ts >> 8), byte(ts), // X-Mms-Expiry: bare long-integer
+			0x8d, 0x92, // 1.2
+		}
+		msg, err := UnmarshalLenient(packet)
+		if err != nil {
+			t.Fatalf("%x: %s", ts, err)
+		}
+		expiry := msg.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime)
+		if expiry.Absolute == nil || expiry.Absolute.Unix() != int64(ts) {
+			t.Errorf("%x: unexpected expiry %+v", ts, expiry)
+		}
+		if got := msg.Header[MMSVersion][0].String(); got != "1.2" {
+			t.Errorf("%x: version got %q", ts, got)
+		}
+	}
+}
+
+func TestApplicationHeaders(t *testing.T) {
+	packet := []byte{0x8c, 0x84}
+	packet = append(packet, "X-Gateway\x00gw1\x00"...)
+	packet = append(packet, 0x8d, 0x92)
+	packet = append(packet, "X-Route\x00a,b\x00"...)
+	packet = append(packet, 0x84, 0xa3, 0x00)
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		"X-Gateway": "gw1",
+		"X-Route":   "a,b",
+	}
+	if !cmp.Equal(msg.AppHeaders, expect) {
+		t.Fatal(cmp.Diff(msg.AppHeaders, expect))
+	}
+	if got := msg.Header[MMSVersion][0].String(); got != "1.2" {
+		t.Fatalf("version got %q", got)
+	}
+	if got := msg.Header[ContentType][0].String(); got != "application/vnd.wap.multipart.mixed" {
+		t.Fatalf("content type got %q", got)
+	}
+}
+
+func TestContentTypeQValue(t *testing.T) {
+	checks := []struct {
+		val    []byte
+		ct     string
+		params map[WellKnownParam]string
+	}{
+		{
+			val:    []byte{0x03, 0x9e, 0x80, 0x47},
+			ct:     "image/jpeg",
+			params: map[WellKnownParam]string{QParam: "0.7"},
+		},
+		{
+			val:    []byte{0x06, 0x9d, 0x80, 0x81, 0x61, 0x81, 0xea},
+			ct:     "image/gif",
+			params: map[WellKnownParam]string{QParam: "0.125", CharsetParam: "UTF-8"},
+		},
+		{
+			val:    []byte{0x03, 0x83, 0x80, 0x64},
+			ct:     "text/plain",
+			params: map[WellKnownParam]string{QParam: "0.99"},
+		},
+		{
+			// A media range with a three decimal q-value ahead of
+			// another parameter.
+			val:    append(append([]byte{0x0d}, "image/*\x00"...), 0x80, 0x83, 0x31, 0x81, 0xea),
+			ct:     "image/*",
+			params: map[WellKnownParam]string{QParam: "0.333", CharsetParam: "UTF-8"},
+		},
+	}
+
+	for _, check := range checks {
+		dec := newBytesDecoder(check.val)
+
+		ct, params, err := dec.decodeContentTypeValue()
+		if err != nil {
+			t.Fatalf("%x: %s", check.val, err)
+		}
+		if ct != check.ct {
+			t.Errorf("%x: content type got %q want %q", check.val, ct, check.ct)
+		}
+		if !cmp.Equal(params, check.params) {
+			t.Errorf("%x: %s", check.val, cmp.Diff(params, check.params))
+		}
+	}
+}
+
+func TestContentTypeUnknownParams(t *testing.T) {
+	val := []byte{0x00, 0x9e}                 // length, image/jpeg
+	val = append(val, 0x84, 'x', 0x00)        // unassigned code, Token-text
+	val = append(val, 0xa0, 0x02, 0x01, 0x00) // unassigned code, Long-integer
+	val = append(val, "foo\x00bar\x00"...)    // untyped
+	val = append(val, 0x81, 0xea)             // charset UTF-8
+	val[0] = byte(len(val) - 1)
+
+	dec := newBytesDecoder(val)
+	ct, params, err := dec.decodeContentTypeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct != "image/jpeg" {
+		t.Errorf("content type got %q", ct)
+	}
+	want := map[WellKnownParam]string{
+		0x84:         "x",
+		0xa0:         "256",
+		CharsetParam: "UTF-8",
+	}
+	if diff := cmp.Diff(want, params); diff != "" {
+		t.Errorf("params mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"foo": "bar"}, dec.untypedParams); diff != "" {
+		t.Errorf("untyped params mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPartUntypedParams(t *testing.T) {
+	partHeaders := []byte{0x0f, 0x9e} // length, image/jpeg
+	partHeaders = append(partHeaders, "format\x00flowed\x00"...)
+
+	packet := []byte{0x8c, 0x84, 0x84, 0xa3, 0x01} // multipart.mixed, one entry
+	packet = append(packet, byte(len(partHeaders)), 0x02)
+	packet = append(packet, partHeaders...)
+	packet = append(packet, "hi"...)
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	if got := msg.Parts[0].Header["Content-Type-format"]; got != "flowed" {
+		t.Errorf("format param got %q want %q", got, "flowed")
+	}
+}
+
+func TestStartField(t *testing.T) {
+	checks := []struct {
+		packet []byte
+		start  HeaderUint
+	}{
+		{[]byte{0x8c, 0x8d, 0x8d, 0x92, 0xaf, 0x85}, 5},
+		{[]byte{0x8c, 0x8d, 0x8d, 0x92, 0xaf, 0x02, 0x01, 0x00}, 256},
+	}
+
+	for _, check := range checks {
+		msg, err := Unmarshal(check.packet)
+		if err != nil {
+			t.Fatalf("%x: %s", check.packet, err)
+		}
+		start, ok := msg.Header[Start][0].(*HeaderUint)
+		if !ok || *start != check.start {
+			t.Errorf("%x: start got %v want %d", check.packet, msg.Header[Start], check.start)
+		}
+	}
+}
+
+func TestBodyLengthByteOrder(t *testing.T) {
+	// A data length of 200 is 0x81 0x48. With the 7-bit groups least
+	// significant first it would read 0xc8 0x01, which is 9217 and so
+	// runs past the data in either mode.
+	data := bytes.Repeat([]byte{'a'}, 200)
+
+	for _, tc := range []struct {
+		name    string
+		dataLen []byte
+	}{
+		{"big-endian", []byte{0x81, 0x48}},
+		{"little-endian", []byte{0xc8, 0x01}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			packet := []byte{
+				0x8c, 0x84, // m-retrieve-conf
+				0x84, 0xa3, // application/vnd.wap.multipart.mixed
+				0x01, // entries
+				0x01, // header length
+			}
+			packet = append(packet, tc.dataLen...)
+			packet = append(packet, 0x83) // text/plain
+			packet = append(packet, data...)
+
+			if tc.name == "little-endian" {
+				if _, err := UnmarshalLenient(packet); !errors.Is(err, ErrTruncated) {
+					t.Errorf("lenient decode err = %v, want ErrTruncated", err)
+				}
+				if _, err := Unmarshal(packet); !errors.Is(err, ErrTruncated) {
+					t.Errorf("strict decode err = %v, want ErrTruncated", err)
+				}
+				return
+			}
+
+			msg, err := UnmarshalLenient(packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(msg.Parts) != 1 {
+				t.Fatalf("got %d parts", len(msg.Parts))
+			}
+			if _, err := Unmarshal(packet); err != nil {
+				t.Errorf("strict decode got err %v", err)
+			}
+			if !bytes.Equal(msg.Parts[0].Data, data) {
+				t.Errorf("got %d bytes of data, want %d", len(msg.Parts[0].Data), len(data))
+			}
+		})
+	}
+}
+
+func TestMMSFieldByName(t *testing.T) {
+	for f, name := range MMSFieldNames {
+		got, ok := MMSFieldByName(name)
+		if !ok || got != f {
+			t.Errorf("MMSFieldByName(%q) = %v, %t; want %v", name, got, ok, f)
+		}
+		if f.String() != name {
+			t.Errorf("%d String() = %q; want %q", f, f.String(), name)
+		}
+	}
+
+	if f, ok := MMSFieldByName("message-id"); !ok || f != MessageID {
+		t.Errorf("case insensitive lookup got %v, %t", f, ok)
+	}
+	if _, ok := MMSFieldByName("X-Not-A-Field"); ok {
+		t.Errorf("unknown name should not be found")
+	}
+}
+
+func TestShortPartHeaderLength(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x03,                   // header length, one byte short
+		0x02,                   // data length
+		0x03, 0x83, 0x81, 0xea, // text/plain; charset=utf-8
+		'h', 'i',
+	}
+
+	if _, err := Unmarshal(packet); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("short part header length err = %v, want ErrInvalidValue", err)
+	}
+
+	msg, err := UnmarshalWithOptions(packet, DecodeOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+	if part.ContentType != "text/plain" || part.Header["Character-Set"] != "UTF-8" || string(part.Data) != "hi" {
+		t.Errorf("got part %+v", part)
+	}
+}
+
+func TestLongPartHeaderLength(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x05,                   // header length, one byte long
+		0x02,                   // data length
+		0x03, 0x83, 0x81, 0xea, // text/plain; charset=utf-8
+		0x01, // stray byte inside the declared header length
+		'h', 'i',
+	}
+
+	if _, err := Unmarshal(packet); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("long part header length err = %v, want ErrInvalidValue", err)
+	}
+
+	msg, err := UnmarshalLenient(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+	if part.ContentType != "text/plain" || part.Header["Character-Set"] != "UTF-8" || string(part.Data) != "hi" {
+		t.Errorf("got part %+v", part)
+	}
+}
+
+func TestEmptyPacket(t *testing.T) {
+	if _, err := Unmarshal(nil); err != ErrTruncated {
+		t.Errorf("Unmarshal(nil) err = %v, want ErrTruncated", err)
+	}
+	if _, err := NewDecoder(bytes.NewReader(nil)).Decode(); err != ErrTruncated {
+		t.Errorf("Decode of empty input err = %v, want ErrTruncated", err)
+	}
+	if _, err := Unmarshal([]byte{0x8c}); err == nil {
+		t.Errorf("Unmarshal of lone field code should fail")
+	}
+}
+
+func TestTextStringValues(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x96, 'h', 'e', 'l', 'l', 'o', 0x00, // Subject: hello
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x2e, 0x01, // header and data lengths
+	}
+	packet = append(packet, 0x1f, 0x2c)
+	packet = append(packet, "application/vnd.oma.drm.message\x00"...)
+	packet = append(packet, 0x81)
+	packet = append(packet, "iso-8859-1\x00"...)
+	packet = append(packet, 'x')
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := msg.Header[Subject][0].String(); got != "hello" {
+		t.Errorf("subject got %q", got)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+	if part.ContentType != "application/vnd.oma.drm.message" {
+		t.Errorf("content type got %q", part.ContentType)
+	}
+	if got := part.Header["Character-Set"]; got != "iso-8859-1" {
+		t.Errorf("charset got %q", got)
+	}
+}
+
+func TestQuotedTextString(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 0x7f, 0xc3, 0xa9, 't', 0x00, // X-Mms-Transaction-Id: quoted "ét"
+		0x8b, 0xc3, 0xa9, 'm', 0x00, // Message-ID: unquoted "ém"
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := msg.Header[TransactionID][0].String(); got != "ét" {
+		t.Errorf("transaction id got %q", got)
+	}
+	// An octet above 127 that isn't the Quote is part of the text.
+	if got := msg.Header[MessageID][0].String(); got != "ém" {
+		t.Errorf("message id got %q", got)
+	}
+}
+
+func TestDateBeyond32Bits(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x85, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00, // Date: 1<<32
+		0x8e, 0x05, 0x01, 0x00, 0x00, 0x00, 0x01, // Message-Size: 1<<32 + 1
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Time(*msg.Header[Date][0].(*HeaderTime))
+	want := time.Date(2106, time.February, 7, 6, 28, 16, 0, time.UTC)
+	if !date.Equal(want) {
+		t.Errorf("date got %s, want %s", date.UTC(), want)
+	}
+	if got := msg.Header[MessageSize][0].String(); got != "4294967297" {
+		t.Errorf("message size got %s", got)
+	}
+}
+
+func TestRetrieveStatus(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 'x', 0x00, // Transaction-ID
+		0x8d, 0x92, // MMS-Version: 1.2
+		0x99, 0xc1, // Retrieve-Status: Error-transient-message-not-found
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, ok := msg.Header[RetrieveStatus][0].(*HeaderRetrieveStatus)
+	if !ok || *status != RetrieveStatusErrorTransientMessageNotFound {
+		t.Fatalf("got retrieve status %v", msg.Header[RetrieveStatus])
+	}
+	if got := status.String(); got != "Error-transient-message-not-found" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRetrieveText(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x98, 't', 'x', 0x00, // Transaction-ID
+		0x8d, 0x92, // MMS-Version: 1.2
+		0x99, 0xe0, // Retrieve-Status: Error-permanent-failure
+		0x9a, 0x0e, 0xea, // Retrieve-Text, UTF-8
+	}
+	packet = append(packet, "Message gone\x00"...)
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := msg.Header[RetrieveText][0].String(); got != "Message gone" {
+		t.Errorf("retrieve text got %q", got)
+	}
+}
+
+func TestReplyChargingFields(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x88, // m-read-orig-ind
+		0x8d, 0x92, // MMS-Version: 1.2
+		0x8b, 'i', 'd', 0x00, // Message-ID
+		0x9b, 0x80, // Read-Status: read
+		0x9c, 0x82, // Reply-Charging: accepted
+		0x9d, 0x03, 0x81, 0x01, 0x3c, // Reply-Charging-Deadline: +60s
+		0x9e, 'r', 'c', 0x00, // Reply-Charging-ID
+		0x9f, 0x02, 0x03, 0xe8, // Reply-Charging-Size: 1000
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, f := range []MMSField{ReadStatus, ReplayCharging, ReplayChargingDeadline, ReplayChargingID, ReplayChargingSize} {
+		got[f.String()] = msg.Header[f][0].String()
+	}
+	want := map[string]string{
+		"Read-Status":              "read",
+		"Replay-Charging":          "accepted",
+		"Replay-Charging-Deadline": "1m0s",
+		"Replay-Charging-ID":       "rc",
+		"Replay-Charging-Size":     "1000",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("field mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEncodedStringCharsets(t *testing.T) {
+	checks := []struct {
+		name  string
+		value []byte
+		want  string
+	}{
+		{"utf-16", []byte{0x0b, 0x02, 0x03, 0xf7, 0xfe, 0xff, 0x00, 'H', 0x00, 'i', 0x00, 0x00}, "Hi"},
+		{"utf-16 single nul", []byte{0x0a, 0x02, 0x03, 0xf7, 0xfe, 0xff, 0x00, 'H', 0x00, 'i', 0x00}, "Hi"},
+		{"utf-8", []byte{0x05, 0xea, 0xc3, 0xa9, 'a', 0x00}, "éa"},
+		{"iso-8859-1", []byte{0x05, 0x84, 'c', 'a', 0xe9, 0x00}, "caé"},
+		{"us-ascii", []byte{0x04, 0x83, 'o', 'k', 0x00}, "ok"},
+		{"windows-1252", []byte{0x05, 0x02, 0x08, 0xcc, 0x80, 0x00}, "€"},
+		{"unknown", []byte{0x05, 0x02, 0x7f, 0x7f, 'x', 0x00}, "x"},
+		{"text-string", []byte{'o', 'k', 0x00}, "ok"},
+		{"quoted text-string", []byte{0x7f, 0xc3, 0xa9, 0x00}, "é"},
+		{"any-charset", []byte{0x04, 0x80, 'o', 'k', 0x00}, "ok"},
+		{"quote as any-charset", []byte{0x04, 0x7f, 'o', 'k', 0x00}, "ok"},
+		{"token-text charset", append(append([]byte{0x09}, "utf-8\x00"...), 0xc3, 0xa9, 0x00), "é"},
+		{"missing charset", []byte{0x03, 'o', 'k', 0x00}, "ok"},
+	}
+
+	for _, check := range checks {
+		packet := append([]byte{0x8c, 0x84, 0x96}, check.value...)
+		msg, err := Unmarshal(packet)
+		if err != nil {
+			t.Errorf("%s: %s", check.name, err)
+			continue
+		}
+		if got := msg.Header[Subject][0].String(); got != check.want {
+			t.Errorf("%s: got %q, want %q", check.name, got, check.want)
+		}
+	}
+}
+
+func TestOversizedLengths(t *testing.T) {
+	// A Uintvar-integer of 0xffffffff.
+	huge := []byte{0x8f, 0xff, 0xff, 0xff, 0x7f}
+
+	header := []byte{0x8c, 0x84} // m-retrieve-conf
+	multipart := []byte{0x84, 0xa3, 0x01}
+
+	cat := func(bs ...[]byte) []byte {
+		var out []byte
+		for _, b := range bs {
+			out = append(out, b...)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		name   string
+		packet []byte
+	}{
+		{"part header length", cat(header, multipart, huge, []byte{0x01, 0x83, 'a'})},
+		{"part data length", cat(header, multipart, []byte{0x01}, huge, []byte{0x83, 'a'})},
+		{"subject", cat(header, []byte{0x96, 0x1f}, huge, []byte{0xea, 'a', 0x00})},
+		{"from", cat(header, []byte{0x89, 0x1f}, huge, []byte{0x80, 'a', 0x00})},
+		{"content type", cat(header, []byte{0x84, 0x1f}, huge, []byte{0xa3})},
+		{"part disposition", cat(header, multipart, []byte{0x08, 0x01, 0x83, 0xae, 0x1f}, huge, []byte{0x81, 'a'})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Unmarshal(tc.packet)
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("Unmarshal err = %v, want ErrTooLarge", err)
+			}
+			_, err = NewDecoder(bytes.NewReader(tc.packet)).Decode()
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("Decode err = %v, want ErrTooLarge", err)
+			}
+
+			// Every length from just past the input up to the limit is
+			// rejected without panicking or allocating the full value.
+			for _, l := range []uint32{uint32(len(tc.packet)), 1 << 16, 1 << 20, DefaultMaxLength} {
+				e := newEncoder()
+				e.encodeVarUint(l)
+				packet := bytes.Replace(tc.packet, huge, e.w.Bytes(), 1)
+				if _, err := Unmarshal(packet); err == nil {
+					t.Errorf("length %d: Unmarshal succeeded", l)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		packet := cat(header, []byte{0xd0, 0x1f}, huge)
+		if _, err := UnmarshalLenient(packet); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("UnmarshalLenient err = %v, want ErrTooLarge", err)
+		}
+	})
+
+	t.Run("max length", func(t *testing.T) {
+		packet := cat(header, []byte{0x84, 0x83}, bytes.Repeat([]byte{'a'}, 100))
+		if _, err := UnmarshalWithOptions(packet, DecodeOptions{MaxLength: 99}); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("UnmarshalWithOptions err = %v, want ErrTooLarge", err)
+		}
+		if _, err := UnmarshalWithOptions(packet, DecodeOptions{MaxLength: 100}); err != nil {
+			t.Errorf("UnmarshalWithOptions err = %v", err)
+		}
+	})
+}
+
+func TestSentinelErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		packet []byte
+		want   error
+	}{
+		{"short int", []byte{0x8c, 0x84, 0x0c}, ErrInvalidShortInt},
+		{"boolean", []byte{0x8c, 0x84, 0x86, 0x82}, ErrInvalidBoolean},
+		{"unknown field", []byte{0x8c, 0x84, 0xd0, 0x80}, ErrUnknownField},
+		{"lone field code", []byte{0x8c}, ErrTruncated},
+		{"unterminated text", []byte{0x8c, 0x84, 0x96, 0x61}, ErrTruncated},
+		{"short long int", []byte{0x8c, 0x84, 0x85, 0x04, 0x01}, ErrTruncated},
+		{"value past end", []byte{0x8c, 0x84, 0x89, 0x05, 0x80}, ErrTruncated},
+		{"missing part", []byte{0x8c, 0x84, 0x84, 0xa3, 0x02, 0x01, 0x01, 0x83, 'a'}, ErrTruncated},
+		{"short part data", []byte{0x8c, 0x84, 0x84, 0xa3, 0x01, 0x01, 0x02, 0x83, 'a'}, ErrTruncated},
+		{"var uint", []byte{0x8c, 0x84, 0x84, 0xa3, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ErrInvalidValue},
+		{"long int", []byte{0x8c, 0x84, 0x85, 0x1f, 0x01}, ErrInvalidValue},
+		{"empty from", []byte{0x8c, 0x84, 0x89, 0x00}, ErrInvalidValue},
+		{"from token", []byte{0x8c, 0x84, 0x89, 0x01, 0x82}, ErrInvalidValue},
+	} {
+		_, err := Unmarshal(tc.packet)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+		_, err = NewDecoder(bytes.NewReader(tc.packet)).Decode()
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: Decoder err = %v, want %v", tc.name, err, tc.want)
+		}
+		if err != nil && !strings.Contains(err.Error(), "pos:") {
+			t.Errorf("%s: err %q has no position", tc.name, err)
+		}
+	}
+}
+
+func TestMessageClassToken(t *testing.T) {
+	for _, tc := range []struct {
+		value []byte
+		want  string
+	}{
+		{[]byte{0x82}, "informational"},
+		{[]byte("x-carrier-promo\x00"), "x-carrier-promo"},
+		{[]byte("\x7fx-quoted\x00"), "x-quoted"},
+		{[]byte{0x00}, ""},
+	} {
+		packet := append([]byte{0x8c, 0x84, 0x8a}, tc.value...)
+		packet = append(packet, 0x8d, 0x92) // MMS-Version 1.2 follows the class
+		msg, err := Unmarshal(packet)
+		if err != nil {
+			t.Errorf("%x: %s", tc.value, err)
+			continue
+		}
+		if got := msg.stringField(MessageClass); got != tc.want {
+			t.Errorf("%x: got class %q want %q", tc.value, got, tc.want)
+		}
+		if v, _ := msg.Version(); v != "1.2" {
+			t.Errorf("%x: got version %q after class", tc.value, v)
+		}
+	}
+}
+
+func TestRawHeaders(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x97, 'a', 0x00, // To: a
+		0x8a, 0x80, // Message-Class: personal
+		0x97, 0x03, 0xea, 'b', 0x00, // To: b with charset
+		0x84, 0x83, // text/plain
+		'h', 'i',
+	}
+
+	msg, err := UnmarshalWithOptions(packet, DecodeOptions{RawHeaders: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[MMSField][]byte{
+		MessageType:  {0x8c, 0x84},
+		To:           {0x97, 'a', 0x00, 0x97, 0x03, 0xea, 'b', 0x00},
+		MessageClass: {0x8a, 0x80},
+		ContentType:  {0x84, 0x83},
+	}
+	if diff := cmp.Diff(want, msg.RawHeaders); diff != "" {
+		t.Errorf("raw headers mismatch (-want +got):\n%s", diff)
+	}
+	if got := msg.RawHeader(MessageClass); !bytes.Equal(got, []byte{0x8a, 0x80}) {
+		t.Errorf("RawHeader(MessageClass) got %x", got)
+	}
+
+	// the recorded bytes are copies
+	packet[6] = 0x81
+	if got := msg.RawHeader(MessageClass); got[1] != 0x80 {
+		t.Errorf("raw header aliases the input: %x", got)
+	}
+
+	msg, err = Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.RawHeaders != nil || msg.RawHeader(To) != nil {
+		t.Errorf("raw headers recorded without the option: %v", msg.RawHeaders)
+	}
+}
+
+// benchmarkPacket returns a multipart message with many small parts,
+// where nested decoders dominate the allocations.
+func benchmarkPacket(b *testing.B) []byte {
+	typ := MRetrieveConf
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-1")},
+			MMSVersion:    {hs("1.2")},
+			From:          {hs("+15555550100/TYPE=PLMN")},
+			To:            {hs("+15555550101/TYPE=PLMN")},
+			Subject:       {hs("benchmark")},
+			ContentType:   {hs("application/vnd.wap.multipart.related")},
+		},
+	}
+	for i := 0; i < 20; i++ {
+		msg.Parts = append(msg.Parts, PDUPart{
+			Header: map[string]string{
+				"Character-Set":    "UTF-8",
+				"Content-Location": fmt.Sprintf("part%d.txt", i),
+				"Name":             fmt.Sprintf("part%d.txt", i),
+			},
+			FileName:    fmt.Sprintf("part%d.txt", i),
+			ContentType: "text/plain",
+			Data:        bytes.Repeat([]byte{'a'}, 200),
+		})
+	}
+	packet, err := Marshal(msg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return packet
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	packet := benchmarkPacket(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(packet)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(packet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// cancelAfter is a context that reports itself canceled once its Err
+// method has been called n times.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n == 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x03,
+		0x01, 0x01, 0x83, 'a', // text/plain
+		0x01, 0x01, 0x83, 'b',
+		0x01, 0x01, 0x83, 'c',
+	}
+
+	msg, err := UnmarshalContext(context.Background(), packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 3 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+
+	// Canceled after the first part.
+	ctx := &cancelAfter{Context: context.Background(), n: 2}
+	if _, err := UnmarshalContext(ctx, packet); !errors.Is(err, context.Canceled) {
+		t.Errorf("mid-decode cancel got err %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := UnmarshalContext(canceled, packet); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context got err %v", err)
+	}
+}
+
+func TestSkipBadParts(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x04,
+		0x01, 0x01, 0x83, 'a', // text/plain
+		0x02, 0x01, 0x83, 0xff, 'b', // unknown part header
+		0x01, 0x01, 0x83, 'c',
+		0x01, 0x05, 0x83, 'd', // truncated
+	}
+
+	if _, err := Unmarshal(packet); err == nil {
+		t.Fatal("expected error without SkipBadParts")
+	}
+
+	msg, err := UnmarshalWithOptions(packet, DecodeOptions{SkipBadParts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []string
+	for _, p := range msg.Parts {
+		data = append(data, string(p.Data))
+	}
+	if diff := cmp.Diff([]string{"a", "c"}, data); diff != "" {
+		t.Errorf("parts mismatch (-want +got):\n%s", diff)
+	}
+	var indexes []int
+	for _, pe := range msg.PartErrors {
+		indexes = append(indexes, pe.Index)
+	}
+	if diff := cmp.Diff([]int{1, 3}, indexes); diff != "" {
+		t.Errorf("part errors mismatch (-want +got):\n%s", diff)
+	}
+
+	dec := NewDecoder(bytes.NewReader(packet))
+	dec.SkipBadParts(true)
+	msg, err = dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 2 || len(msg.PartErrors) != 2 {
+		t.Errorf("stream decode got %d parts, errors %v", len(msg.Parts), msg.PartErrors)
+	}
 }
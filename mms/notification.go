@@ -0,0 +1,177 @@
+package mms
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultNotificationExpiry is the relative expiry given to a
+// notification derived from a message that carries no Expiry.
+const defaultNotificationExpiry = 7 * 24 * time.Hour
+
+// defaultVersion is the MMS version of the PDUs this package builds.
+const defaultVersion = "1.2"
+
+// ToNotification returns the m-notification-ind that would announce m,
+// with its Content-Location set to contentLocation.
+//
+// From, Subject, Message-Class, Message-Size and Expiry are taken from
+// m. When m doesn't carry them, Message-Class defaults to personal,
+// Message-Size to the length of m encoded by Marshal, or the total
+// size of the part data if m can't be encoded, and Expiry to seven
+// days after delivery. The Transaction-ID is m's Transaction-ID, or
+// its Message-ID if it has none, and MMS-Version is m's version,
+// defaulting to 1.2.
+func (m *Message) ToNotification(contentLocation string) (*Message, error) {
+	if contentLocation == "" {
+		return nil, errors.New("notification requires a content location")
+	}
+
+	tid := m.stringField(TransactionID)
+	if tid == "" {
+		tid = m.stringField(MessageID)
+	}
+	if tid == "" {
+		return nil, errors.New("message has no Transaction-ID or Message-ID")
+	}
+
+	notif := Message{
+		Header: make(map[MMSField][]HeaderField),
+	}
+	set := func(f MMSField, v HeaderField) {
+		notif.Header[f] = []HeaderField{v}
+	}
+
+	typ := MNotificationInd
+	set(MessageType, &typ)
+	tidVal := HeaderString(tid)
+	set(TransactionID, &tidVal)
+	version, ok := m.Version()
+	if !ok {
+		version = defaultVersion
+	}
+	versionVal := HeaderString(version)
+	set(MMSVersion, &versionVal)
+
+	for _, f := range []MMSField{From, Subject} {
+		for _, v := range m.Header[f] {
+			notif.Header[f] = append(notif.Header[f], cloneHeaderField(v))
+		}
+	}
+
+	if v := m.field(MessageClass); v != nil {
+		set(MessageClass, cloneHeaderField(v))
+	} else {
+		cls := HeaderString("personal")
+		set(MessageClass, &cls)
+	}
+
+	if v := m.field(MessageSize); v != nil {
+		set(MessageSize, cloneHeaderField(v))
+	} else {
+		var size HeaderUint
+		if packet, err := Marshal(m); err == nil {
+			size = HeaderUint(len(packet))
+		} else {
+			for _, p := range m.Parts {
+				size += HeaderUint(len(p.Data))
+			}
+		}
+		set(MessageSize, &size)
+	}
+
+	if v := m.field(Expiry); v != nil {
+		set(Expiry, cloneHeaderField(v))
+	} else {
+		rel := defaultNotificationExpiry
+		set(Expiry, &HeaderRelativeOrAbsoluteTime{Relative: &rel})
+	}
+
+	loc := HeaderString(contentLocation)
+	set(ContentLocation, &loc)
+
+	return &notif, nil
+}
+
+// NewNotificationInd encodes an MMS 1.2 m-notification-ind announcing
+// a message of size bytes that can be fetched from contentLocation
+// until expiry, as an MMSC would send it. The Transaction-ID is random
+// and the Message-Class is personal. expiry is sent as an absolute
+// X-Mms-Expiry, so it is truncated to whole seconds.
+func NewNotificationInd(contentLocation string, size uint64, expiry time.Time) ([]byte, error) {
+	if contentLocation == "" {
+		return nil, errors.New("notification requires a content location")
+	}
+	if expiry.IsZero() {
+		return nil, errors.New("notification requires an expiry")
+	}
+	tid, err := newTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	typ := MNotificationInd
+	tidVal := HeaderString(tid)
+	version := HeaderString(defaultVersion)
+	cls := HeaderString("personal")
+	sizeVal := HeaderUint(size)
+	loc := HeaderString(contentLocation)
+	notif := Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:     {&typ},
+			TransactionID:   {&tidVal},
+			MMSVersion:      {&version},
+			MessageClass:    {&cls},
+			MessageSize:     {&sizeVal},
+			Expiry:          {&HeaderRelativeOrAbsoluteTime{Absolute: &expiry}},
+			ContentLocation: {&loc},
+		},
+	}
+	return Marshal(&notif)
+}
+
+// NewNotifyRespInd encodes the MMS 1.2 m-notifyresp-ind a client sends
+// in reply to the m-notification-ind with transactionID, reporting
+// status. Report-Allowed is set to yes.
+func NewNotifyRespInd(transactionID string, status HeaderStatus) ([]byte, error) {
+	if status < 128 || status > 255 {
+		return nil, fmt.Errorf("invalid status %d", status)
+	}
+	resp, err := clientResponse(MNotifyrespInd, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header[StatusField] = []HeaderField{&status}
+	return Marshal(resp)
+}
+
+// NewAcknowledgeInd encodes the MMS 1.2 m-acknowledge-ind a client
+// sends to confirm it retrieved the message delivered by the
+// m-retrieve-conf with transactionID. Report-Allowed is set to yes.
+func NewAcknowledgeInd(transactionID string) ([]byte, error) {
+	resp, err := clientResponse(MAcknowledgeInd, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(resp)
+}
+
+// clientResponse returns a client response PDU of type typ with the
+// fields the response types share.
+func clientResponse(typ HeaderMessageType, transactionID string) (*Message, error) {
+	if transactionID == "" {
+		return nil, errors.New("response requires a transaction id")
+	}
+	tid := HeaderString(transactionID)
+	version := HeaderString(defaultVersion)
+	reportAllowed := HeaderBool(true)
+	return &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {&tid},
+			MMSVersion:    {&version},
+			ReportAllowed: {&reportAllowed},
+		},
+	}, nil
+}
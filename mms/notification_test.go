@@ -0,0 +1,196 @@
+package mms
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToNotification(t *testing.T) {
+	retrieved := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {func() *HeaderMessageType { t := MRetrieveConf; return &t }()},
+			MessageID:   {hs("msg-1")},
+			MMSVersion:  {hs("1.2")},
+			From:        {hs("+15555550100/TYPE=PLMN")},
+			Subject:     {hs("hello")},
+			To:          {hs("+15555550101/TYPE=PLMN")},
+		},
+		Parts: []PDUPart{
+			{ContentType: "text/plain", Data: []byte("hi there")},
+			{ContentType: "image/jpeg", Data: make([]byte, 100)},
+		},
+	}
+
+	notif, err := retrieved.ToNotification("http://mmsc.example.com/msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for f, vals := range notif.Header {
+		got[f.String()] = vals[0].String()
+	}
+	want := map[string]string{
+		"Message-Type":     "m-notification-ind",
+		"Transaction-ID":   "msg-1",
+		"MMS-Version":      "1.2",
+		"From":             "+15555550100/TYPE=PLMN",
+		"Subject":          "hello",
+		"Message-Class":    "personal",
+		"Message-Size":     "108",
+		"Expiry":           (7 * 24 * time.Hour).String(),
+		"Content-Location": "http://mmsc.example.com/msg-1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("notification header mismatch (-want +got):\n%s", diff)
+	}
+	if len(notif.Parts) != 0 {
+		t.Errorf("notification should have no parts, got %d", len(notif.Parts))
+	}
+
+	size := HeaderUint(500)
+	expiry := time.Hour
+	retrieved.Header[MessageSize] = []HeaderField{&size}
+	retrieved.Header[Expiry] = []HeaderField{&HeaderRelativeOrAbsoluteTime{Relative: &expiry}}
+	retrieved.Header[MessageClass] = []HeaderField{hs("advertisement")}
+	notif, err = retrieved.ToNotification("http://mmsc.example.com/msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := headerStrings(retrieved)
+	*notif.Header[From][0].(*HeaderString) = "+15555550199/TYPE=PLMN"
+	*notif.Header[Subject][0].(*HeaderString) = "changed"
+	*notif.Header[MessageClass][0].(*HeaderString) = "auto"
+	*notif.Header[MessageSize][0].(*HeaderUint) = 1
+	*notif.Header[Expiry][0].(*HeaderRelativeOrAbsoluteTime).Relative = time.Minute
+	if diff := cmp.Diff(before, headerStrings(retrieved)); diff != "" {
+		t.Errorf("changing the notification changed the source (-want +got):\n%s", diff)
+	}
+
+	if _, err := retrieved.ToNotification(""); err == nil {
+		t.Errorf("expected error for empty content location")
+	}
+}
+
+func TestToNotificationDefaults(t *testing.T) {
+	typ := MSendReq
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType:   {&typ},
+			TransactionID: {hs("tx-1")},
+			ContentType:   {hs("text/plain")},
+		},
+		Parts: []PDUPart{
+			{Header: map[string]string{}, ContentType: "text/plain", Data: []byte("hi there")},
+		},
+	}
+	packet, err := Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notif, err := msg.ToNotification("http://mmsc.example.com/tx-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := notif.Version(); !ok || v != "1.2" {
+		t.Errorf("version got %q, %t", v, ok)
+	}
+	if got := notif.Header[MessageSize][0].String(); got != strconv.Itoa(len(packet)) {
+		t.Errorf("message size got %s want %d", got, len(packet))
+	}
+	if _, err := Marshal(notif); err != nil {
+		t.Errorf("marshal notification: %s", err)
+	}
+}
+
+func TestNewNotificationInd(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+	packet, err := NewNotificationInd("http://mmsc.example.com/msg-1", 70000, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := headerStrings(msg)
+	if tid := got["Transaction-ID"]; len(tid) != 1 || tid[0] == "" {
+		t.Errorf("missing transaction id: %v", tid)
+	}
+	delete(got, "Transaction-ID")
+	want := map[string][]string{
+		"Message-Type":     {"m-notification-ind"},
+		"MMS-Version":      {"1.2"},
+		"Message-Class":    {"personal"},
+		"Message-Size":     {"70000"},
+		"Expiry":           {expiry.Format(time.RFC3339)},
+		"Content-Location": {"http://mmsc.example.com/msg-1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("notification header mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewNotificationInd("", 1, expiry); err == nil {
+		t.Errorf("expected error for empty content location")
+	}
+	if _, err := NewNotificationInd("http://mmsc.example.com/msg-1", 1, time.Time{}); err == nil {
+		t.Errorf("expected error for zero expiry")
+	}
+}
+
+func TestClientResponses(t *testing.T) {
+	packet, err := NewNotifyRespInd("tx-1", StatusDeferred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x8c, 0x83, // m-notifyresp-ind
+		0x98, 't', 'x', '-', '1', 0x00, // X-Mms-Transaction-Id: tx-1
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x91, 0x80, // X-Mms-Report-Allowed: yes
+		0x95, 0x83, // X-Mms-Status: deferred
+	}
+	if diff := cmp.Diff(want, packet); diff != "" {
+		t.Errorf("notifyresp mismatch (-want +got):\n%s", diff)
+	}
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := headerStrings(msg)["Status"]; !cmp.Equal(got, []string{"deferred"}) {
+		t.Errorf("status got %v", got)
+	}
+
+	packet, err = NewAcknowledgeInd("tx-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err = Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := map[string][]string{
+		"Message-Type":   {"m-acknowledge-ind"},
+		"Transaction-ID": {"tx-2"},
+		"MMS-Version":    {"1.2"},
+		"Report-Allowed": {"true"},
+	}
+	if diff := cmp.Diff(wantHeader, headerStrings(msg)); diff != "" {
+		t.Errorf("acknowledge mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewNotifyRespInd("", StatusRetrieved); err == nil {
+		t.Errorf("expected error for empty transaction id")
+	}
+	if _, err := NewNotifyRespInd("tx-1", 7); err == nil {
+		t.Errorf("expected error for invalid status")
+	}
+	if _, err := NewAcknowledgeInd(""); err == nil {
+		t.Errorf("expected error for empty transaction id")
+	}
+}
@@ -0,0 +1,254 @@
+package mms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Text returns the body of the part decoded to a UTF-8 string. The
+// part's declared charset is used, falling back to DefaultCharset and
+// then US-ASCII when none is declared. Undeclared text that isn't
+// valid UTF-8 under a UTF-8 default is decoded as ISO-8859-1 instead;
+// invalid sequences in declared UTF-8 are replaced with U+FFFD.
+// Any Content-Transfer-Encoding is removed first, as by DecodedData.
+// An empty part yields an empty string.
+func (p *PDUPart) Text() (string, error) {
+	data, err := p.DecodedData()
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	charset := p.Header["Character-Set"]
+	if charset == "" {
+		charset = p.DefaultCharset
+		if strings.EqualFold(charset, "UTF-8") && !utf8.Valid(data) {
+			charset = "ISO-8859-1"
+		}
+	}
+	if charset == "" {
+		charset = "US-ASCII"
+	}
+
+	return decodeCharset(charset, data), nil
+}
+
+// DecodedData returns the part body decoded according to its
+// Content-Transfer-Encoding header. base64 and quoted-printable bodies
+// are decoded; binary, 7bit, 8bit and absent encodings return Data
+// unchanged. Data itself is never modified.
+func (p *PDUPart) DecodedData() ([]byte, error) {
+	cte := p.Header["Content-Transfer-Encoding"]
+	switch strings.ToLower(strings.TrimSpace(cte)) {
+	case "", "binary", "7bit", "8bit":
+		return p.Data, nil
+	case "base64", "quoted-printable":
+		return decodeTransferEncoding(cte, bytes.NewReader(p.Data))
+	}
+	return nil, fmt.Errorf("unsupported content transfer encoding %q", cte)
+}
+
+// WriteTo writes the part body, decoded as by DecodedData, to w,
+// implementing io.WriterTo. Data is written directly, without a copy,
+// when no transfer decoding is needed.
+func (p *PDUPart) WriteTo(w io.Writer) (int64, error) {
+	data, err := p.DecodedData()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// IsPresentation reports whether the part is a SMIL presentation.
+// Parameters and letter case in the content type are ignored.
+func (p *PDUPart) IsPresentation() bool {
+	return mediaType(p.ContentType) == "application/smil"
+}
+
+// mediaType returns the lowercased media type of a content type value,
+// without any parameters.
+func mediaType(contentType string) string {
+	if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// SniffedContentType returns the media type detected from the part's
+// data, decoded as by DecodedData, by http.DetectContentType, without
+// parameters. AMR audio and 3GPP video, which are common in MMS but
+// unknown to http.DetectContentType, are recognised as well. An empty
+// part is reported as application/octet-stream. Data whose transfer
+// encoding can't be decoded is sniffed as is.
+func (p *PDUPart) SniffedContentType() string {
+	data, err := p.DecodedData()
+	if err != nil {
+		data = p.Data
+	}
+
+	switch {
+	case len(data) == 0:
+		return "application/octet-stream"
+	case bytes.HasPrefix(data, []byte("#!AMR-WB\n")):
+		return "audio/amr-wb"
+	case bytes.HasPrefix(data, []byte("#!AMR\n")):
+		return "audio/amr"
+	case len(data) >= 11 && string(data[4:11]) == "ftyp3gp":
+		return "video/3gpp"
+	}
+	return mediaType(http.DetectContentType(data))
+}
+
+// sniffAliases maps declared media types seen from devices to the
+// canonical type reported by http.DetectContentType.
+var sniffAliases = map[string]string{
+	"image/jpg":       "image/jpeg",
+	"image/pjpeg":     "image/jpeg",
+	"audio/mp3":       "audio/mpeg",
+	"audio/wav":       "audio/wave",
+	"audio/x-wav":     "audio/wave",
+	"audio/mid":       "audio/midi",
+	"video/x-msvideo": "video/avi",
+}
+
+// ContentTypeMatches reports whether the part's declared content type
+// is consistent with its sniffed one. Data with no recognised
+// signature always matches, and since sniffing can't tell text formats
+// such as SMIL or vCard apart, text data matches any declared type
+// other than image, audio and video.
+func (p *PDUPart) ContentTypeMatches() bool {
+	declared := mediaType(p.ContentType)
+	sniffed := p.SniffedContentType()
+
+	switch {
+	case sniffed == "application/octet-stream":
+		return true
+	case strings.HasPrefix(sniffed, "text/"):
+		top, _, _ := strings.Cut(declared, "/")
+		return top != "image" && top != "audio" && top != "video"
+	}
+
+	if alias, ok := sniffAliases[declared]; ok {
+		declared = alias
+	}
+	return declared == sniffed
+}
+
+// PartMetadata holds typed metadata decoded from a part's content type
+// and content disposition parameters. Absent dates are the zero time.
+type PartMetadata struct {
+	CreationDate     time.Time
+	ModificationDate time.Time
+	ReadDate         time.Time
+}
+
+// Metadata returns the typed metadata of the part. A date given as a
+// content type parameter takes precedence over the same date given as
+// a Content-Disposition parameter.
+func (p *PDUPart) Metadata() PartMetadata {
+	parse := func(key string) time.Time {
+		v, ok := p.Header[key]
+		if !ok {
+			v = p.Header[dispositionParamPrefix+key]
+		}
+		t, _ := time.Parse(time.RFC3339, v)
+		return t
+	}
+	return PartMetadata{
+		CreationDate:     parse("Creation-Date"),
+		ModificationDate: parse("Modification-Date"),
+		ReadDate:         parse("Read-Date"),
+	}
+}
+
+// StrippedData returns the part body, decoded as by DecodedData, with
+// identifying metadata removed. For JPEG images the APP1 (Exif and XMP,
+// including GPS coordinates) and APP13 (IPTC) segments are dropped; the
+// image data itself is copied unchanged. Other content types return
+// the decoded body as is.
+func (p *PDUPart) StrippedData() ([]byte, error) {
+	data, err := p.DecodedData()
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType(p.ContentType) {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return stripJPEGMetadata(data)
+	}
+	return data, nil
+}
+
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	const (
+		markerSOI   = 0xd8
+		markerEOI   = 0xd9
+		markerSOS   = 0xda
+		markerAPP1  = 0xe1
+		markerAPP13 = 0xed
+	)
+
+	if len(data) < 2 || data[0] != 0xff || data[1] != markerSOI {
+		return nil, errors.New("invalid jpeg: missing SOI marker")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xff {
+			return nil, fmt.Errorf("invalid jpeg: expected marker at offset %d", i)
+		}
+		// markers may be preceded by any number of 0xff fill bytes
+		for i < len(data) && data[i] == 0xff {
+			i++
+		}
+		if i >= len(data) {
+			return nil, errors.New("invalid jpeg: truncated marker")
+		}
+		marker := data[i]
+		i++
+
+		// standalone markers carry no length
+		if marker == markerEOI || marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			out = append(out, 0xff, marker)
+			if marker == markerEOI {
+				return append(out, data[i:]...), nil
+			}
+			continue
+		}
+
+		if i+2 > len(data) {
+			return nil, errors.New("invalid jpeg: truncated segment length")
+		}
+		segLen := int(data[i])<<8 | int(data[i+1])
+		if segLen < 2 || i+segLen > len(data) {
+			return nil, fmt.Errorf("invalid jpeg: bad segment length at offset %d", i)
+		}
+		segment := data[i : i+segLen]
+		i += segLen
+
+		if marker == markerAPP1 || marker == markerAPP13 {
+			continue
+		}
+
+		out = append(out, 0xff, marker)
+		out = append(out, segment...)
+
+		if marker == markerSOS {
+			// entropy coded data follows; there is no more metadata
+			return append(out, data[i:]...), nil
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,587 @@
+package mms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEmptyTextPart(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8d, 0x92, // 1.2
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+
+		0x03, // 3 parts
+
+		0x01, 0x00, // header len 1, data len 0
+		0x83, // text/plain
+
+		0x01, 0x02, // header len 1, data len 2
+		0x83, // text/plain
+		'h', 'i',
+
+		0x01, 0x00, // header len 1, data len 0
+		0x83, // text/plain
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msg.Parts) != 3 {
+		t.Fatalf("got %d parts, want 3", len(msg.Parts))
+	}
+
+	expect := []string{"", "hi", ""}
+	for i, part := range msg.Parts {
+		if part.ContentType != "text/plain" {
+			t.Errorf("part %d content type got %q", i, part.ContentType)
+		}
+		txt, err := part.Text()
+		if err != nil {
+			t.Fatalf("part %d: %s", i, err)
+		}
+		if txt != expect[i] {
+			t.Errorf("part %d text got %q want %q", i, txt, expect[i])
+		}
+	}
+}
+
+func TestTextCharset(t *testing.T) {
+	body := func(version byte) []byte {
+		return []byte{
+			0x8c, 0x84, // m-retrieve-conf
+			0x8d, version,
+			0x84, 0xa3, // application/vnd.wap.multipart.mixed
+			0x03,
+
+			// text/plain, no charset
+			0x01, 0x03, 0x83, 'c', 'a', 0xe9,
+
+			// text/plain; charset=iso-8859-1
+			0x04, 0x03, 0x03, 0x83, 0x81, 0x84, 'c', 'a', 0xe9,
+
+			// text/plain; charset=utf-16
+			0x06, 0x06, 0x05, 0x83, 0x81, 0x02, 0x03, 0xf7, 0xfe, 0xff, 0x00, 'h', 0x00, 'i',
+		}
+	}
+
+	checks := []struct {
+		name           string
+		packet         []byte
+		defaultCharset string
+		expect         []string
+	}{
+		{
+			name:   "1.2",
+			packet: body(0x92),
+			expect: []string{"ca�", "caé", "hi"},
+		},
+		{
+			name:   "1.3",
+			packet: body(0x93),
+			expect: []string{"caé", "caé", "hi"},
+		},
+		{
+			name:           "forced",
+			packet:         body(0x92),
+			defaultCharset: "ISO-8859-1",
+			expect:         []string{"caé", "caé", "hi"},
+		},
+	}
+
+	for _, check := range checks {
+		dec := NewDecoder(bytes.NewReader(check.packet))
+		if check.defaultCharset != "" {
+			dec.DefaultCharset(check.defaultCharset)
+		}
+		msg, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("%s: %s", check.name, err)
+		}
+		if len(msg.Parts) != len(check.expect) {
+			t.Fatalf("%s: got %d parts", check.name, len(msg.Parts))
+		}
+		for i, part := range msg.Parts {
+			txt, err := part.Text()
+			if err != nil {
+				t.Fatalf("%s part %d: %s", check.name, i, err)
+			}
+			if txt != check.expect[i] {
+				t.Errorf("%s part %d: got %q want %q", check.name, i, txt, check.expect[i])
+			}
+		}
+	}
+}
+
+func TestTextDefaultUTF8(t *testing.T) {
+	checks := []struct {
+		data   string
+		header map[string]string
+		expect string
+	}{
+		{data: "caf\xc3\xa9", expect: "café"},
+		{data: "caf\xe9", expect: "café"},
+		{data: "caf\xe9", header: map[string]string{"Character-Set": "UTF-8"}, expect: "caf\uFFFD"},
+		{data: "a\xffb\xc3", header: map[string]string{"Character-Set": "UTF-8"}, expect: "a\uFFFDb\uFFFD"},
+		{data: "caf\xe9", header: map[string]string{"Character-Set": "X-Unknown"}, expect: "caf\uFFFD"},
+	}
+
+	for _, check := range checks {
+		part := PDUPart{
+			Header:         check.header,
+			Data:           []byte(check.data),
+			DefaultCharset: "UTF-8",
+		}
+		txt, err := part.Text()
+		if err != nil {
+			t.Fatalf("%q: %s", check.data, err)
+		}
+		if txt != check.expect {
+			t.Errorf("%q: got %q want %q", check.data, txt, check.expect)
+		}
+	}
+}
+
+func TestLargePartLengths(t *testing.T) {
+	pad := bytes.Repeat([]byte{'a'}, 192)
+	data := bytes.Repeat([]byte{'d'}, 300)
+
+	var headers []byte
+	headers = append(headers, 0x83) // text/plain
+	headers = append(headers, "X-Pad\x00"...)
+	headers = append(headers, pad...)
+	headers = append(headers, 0x00)
+	if len(headers) != 200 {
+		t.Fatalf("bad fixture header length %d", len(headers))
+	}
+
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x02,
+		0x81, 0x48, // header len 200
+		0x82, 0x2c, // data len 300
+	}
+	packet = append(packet, headers...)
+	packet = append(packet, data...)
+	packet = append(packet, 0x01, 0x01, 0x83, 'z')
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msg.Parts) != 2 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	if got := msg.Parts[0].Header["X-Pad"]; got != string(pad) {
+		t.Errorf("X-Pad got %d bytes", len(got))
+	}
+	if !bytes.Equal(msg.Parts[0].Data, data) {
+		t.Errorf("data got %d bytes", len(msg.Parts[0].Data))
+	}
+	if string(msg.Parts[1].Data) != "z" {
+		t.Errorf("second part data got %q", msg.Parts[1].Data)
+	}
+}
+
+func TestStrippedData(t *testing.T) {
+	jpeg := []byte{
+		0xff, 0xd8, // SOI
+		0xff, 0xe0, 0x00, 0x04, 'J', 'F', // APP0
+		0xff, 0xe1, 0x00, 0x08, 'E', 'x', 'i', 'f', 0x00, 0x00, // APP1
+		0xff, 0xed, 0x00, 0x03, 'P', // APP13
+		0xff, 0xdb, 0x00, 0x03, 0x01, // DQT
+		0xff, 0xda, 0x00, 0x03, 0x02, // SOS
+		0x12, 0xff, 0x00, 0x34, // entropy coded data
+		0xff, 0xd9, // EOI
+	}
+	expect := []byte{
+		0xff, 0xd8,
+		0xff, 0xe0, 0x00, 0x04, 'J', 'F',
+		0xff, 0xdb, 0x00, 0x03, 0x01,
+		0xff, 0xda, 0x00, 0x03, 0x02,
+		0x12, 0xff, 0x00, 0x34,
+		0xff, 0xd9,
+	}
+
+	part := PDUPart{
+		ContentType: "image/jpeg",
+		Data:        jpeg,
+	}
+	got, err := part.StrippedData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expect) {
+		t.Fatalf("got %x\nwant %x", got, expect)
+	}
+
+	part.ContentType = "Image/JPEG; name=a.jpg"
+	got, err = part.StrippedData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expect) {
+		t.Fatalf("with parameters got %x\nwant %x", got, expect)
+	}
+
+	part.Data = []byte("not a jpeg")
+	if _, err := part.StrippedData(); err == nil {
+		t.Fatal("expected error for invalid jpeg")
+	}
+
+	part = PDUPart{
+		ContentType: "text/plain",
+		Data:        []byte("hi"),
+	}
+	got, err = part.StrippedData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hi" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSinglePartMessage(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8d, 0x92, // 1.2
+		0x84, 0x0c, 0x9e, 0x85, 'c', 'a', 't', '.', 'j', 'p', 'g', 0x00, 0x81, 0xea, // image/jpeg; name=cat.jpg; charset=utf-8
+		0x05, 0xff, 0xd8, 0xff, 0xd9, // body
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+	if part.ContentType != "image/jpeg" {
+		t.Errorf("content type got %q", part.ContentType)
+	}
+	if part.FileName != "cat.jpg" || part.Header["Name"] != "cat.jpg" {
+		t.Errorf("unexpected name %q %q", part.FileName, part.Header["Name"])
+	}
+	if !bytes.Equal(part.Data, []byte{0x05, 0xff, 0xd8, 0xff, 0xd9}) {
+		t.Errorf("data got %x", part.Data)
+	}
+}
+
+func TestIsPresentation(t *testing.T) {
+	checks := map[string]bool{
+		"application/smil":                      true,
+		"Application/SMIL":                      true,
+		"application/smil; charset=utf-8":       true,
+		"application/smil ":                     true,
+		"application/vnd.wap.multipart.related": false,
+		"text/plain":                            false,
+		"":                                      false,
+	}
+	for ct, expect := range checks {
+		part := PDUPart{ContentType: ct}
+		if got := part.IsPresentation(); got != expect {
+			t.Errorf("%q: got %t want %t", ct, got, expect)
+		}
+	}
+
+	msg := Message{
+		Parts: []PDUPart{
+			{ContentType: "text/plain"},
+			{ContentType: "APPLICATION/SMIL"},
+		},
+	}
+	part, ok := msg.PresentationPart()
+	if !ok || part != &msg.Parts[1] {
+		t.Fatalf("unexpected presentation part %v %t", part, ok)
+	}
+}
+
+func TestPartMetadata(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x11, 0x01,
+		// image/jpeg; creation-date=1700000000
+		0x07, 0x9e, 0x93, 0x04, 0x65, 0x53, 0xf1, 0x00,
+		// Content-Disposition: attachment; read-date=1700000001
+		0xc5, 0x07, 0x81, 0x95, 0x04, 0x65, 0x53, 0xf1, 0x01,
+		'x',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+
+	md := msg.Parts[0].Metadata()
+	if !md.CreationDate.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("creation date got %s", md.CreationDate)
+	}
+	if !md.ReadDate.Equal(time.Unix(1700000001, 0)) {
+		t.Errorf("read date got %s", md.ReadDate)
+	}
+	if !md.ModificationDate.IsZero() {
+		t.Errorf("modification date got %s", md.ModificationDate)
+	}
+}
+
+func TestDispositionDates(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x11, 0x01,
+		// image/jpeg; creation-date=1700000000
+		0x07, 0x9e, 0x93, 0x04, 0x65, 0x53, 0xf1, 0x00,
+		// Content-Disposition: attachment; creation-date=1600000000
+		0xc5, 0x07, 0x81, 0x93, 0x04, 0x5f, 0x5e, 0x10, 0x00,
+		'x',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := msg.Parts[0]
+	want := map[string]string{
+		"Creation-Date":                     "2023-11-14T22:13:20Z",
+		"Content-Disposition":               "AttachmentDisposition",
+		"Content-Disposition-Creation-Date": "2020-09-13T12:26:40Z",
+	}
+	if diff := cmp.Diff(want, part.Header); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	if md := part.Metadata(); !md.CreationDate.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("creation date got %s", md.CreationDate)
+	}
+
+	checkRoundTrip(t, msg)
+
+	delete(part.Header, "Creation-Date")
+	if md := part.Metadata(); !md.CreationDate.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("disposition creation date got %s", md.CreationDate)
+	}
+}
+
+func TestDispositionParams(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x0f, 0x01, // header and data lengths
+		0x9e, // image/jpeg
+		// Content-Disposition: attachment; deprecated filename=a.jpg; size=1000
+		0xc5, 0x0c, 0x81, 0x86, 'a', '.', 'j', 'p', 'g', 0x00, 0x96, 0x02, 0x03, 0xe8,
+		'x',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+	if part.FileName != "a.jpg" {
+		t.Errorf("filename got %q", part.FileName)
+	}
+	want := map[string]string{
+		"Content-Disposition":      "AttachmentDisposition",
+		"Content-Disposition-Size": "1000",
+	}
+	if diff := cmp.Diff(want, part.Header); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+
+	checkRoundTrip(t, msg)
+}
+
+func TestContentTypeMatches(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F'}
+
+	checks := []struct {
+		contentType string
+		data        []byte
+		sniffed     string
+		matches     bool
+	}{
+		{"image/png", png, "image/png", true},
+		{"image/jpeg", png, "image/png", false},
+		{"image/jpg", jpeg, "image/jpeg", true},
+		{"application/smil", []byte("<smil><body></body></smil>"), "text/plain", true},
+		{"image/jpeg", []byte("hello"), "text/plain", false},
+		{"audio/amr", []byte("#!AMR\n\x3c\x91\x17\x16"), "audio/amr", true},
+		{"video/3gpp", []byte("\x00\x00\x00\x18ftyp3gp4"), "video/3gpp", true},
+		{"image/jpeg", []byte("#!AMR\n\x3c\x91\x17\x16"), "audio/amr", false},
+		{"application/vnd.example", []byte{0x00, 0x01, 0x02}, "application/octet-stream", true},
+		{"text/plain", nil, "application/octet-stream", true},
+	}
+
+	for _, check := range checks {
+		p := PDUPart{ContentType: check.contentType, Data: check.data}
+		if got := p.SniffedContentType(); got != check.sniffed {
+			t.Errorf("%s %q: sniffed %q, want %q", check.contentType, check.data, got, check.sniffed)
+		}
+		if got := p.ContentTypeMatches(); got != check.matches {
+			t.Errorf("%s %q: matches %t, want %t", check.contentType, check.data, got, check.matches)
+		}
+	}
+}
+
+func TestContentTransferEncodingHeader(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x09, 0x04, // header and data lengths
+		0x9e,                                     // image/jpeg
+		0xc8, 'b', 'a', 's', 'e', '6', '4', 0x00, // Content-Transfer-Encoding: base64
+		'/', '9', 'j', '/',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Parts[0].Header["Content-Transfer-Encoding"]; got != "base64" {
+		t.Errorf("got Content-Transfer-Encoding %q", got)
+	}
+}
+
+func TestDecodedData(t *testing.T) {
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0xff, 0xd9}
+
+	checks := []struct {
+		cte  string
+		data string
+		want []byte
+	}{
+		{"base64", "/9j/4AAQSkZJRgD/2Q==", jpeg},
+		{"BASE64", "/9j/4AAQ\r\nSkZJRgD/2Q==", jpeg},
+		{"quoted-printable", "caf=C3=A9", []byte("café")},
+		{"binary", "raw", []byte("raw")},
+		{"", "raw", []byte("raw")},
+	}
+
+	for _, check := range checks {
+		p := PDUPart{
+			Header: map[string]string{"Content-Transfer-Encoding": check.cte},
+			Data:   []byte(check.data),
+		}
+		got, err := p.DecodedData()
+		if err != nil {
+			t.Errorf("%s: %s", check.cte, err)
+			continue
+		}
+		if !bytes.Equal(got, check.want) {
+			t.Errorf("%s: got %q, want %q", check.cte, got, check.want)
+		}
+		if string(p.Data) != check.data {
+			t.Errorf("%s: Data was modified", check.cte)
+		}
+	}
+
+	p := PDUPart{
+		Header: map[string]string{"Content-Transfer-Encoding": "x-uuencode"},
+		Data:   []byte("begin"),
+	}
+	if _, err := p.DecodedData(); err == nil {
+		t.Errorf("expected error for unsupported encoding")
+	}
+}
+
+func TestBase64ImageAccessors(t *testing.T) {
+	jpeg := []byte{
+		0xff, 0xd8, // SOI
+		0xff, 0xe0, 0x00, 0x04, 'J', 'F', // APP0
+		0xff, 0xe1, 0x00, 0x04, 'E', 'x', // APP1
+		0xff, 0xd9, // EOI
+	}
+	p := PDUPart{
+		Header:      map[string]string{"Content-Transfer-Encoding": "base64"},
+		ContentType: "image/jpeg",
+		Data:        []byte(base64.StdEncoding.EncodeToString(jpeg)),
+	}
+
+	if got := p.SniffedContentType(); got != "image/jpeg" {
+		t.Errorf("SniffedContentType got %q", got)
+	}
+	if !p.ContentTypeMatches() {
+		t.Errorf("ContentTypeMatches got false")
+	}
+	stripped, err := p.StrippedData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x04, 'J', 'F', 0xff, 0xd9}
+	if !bytes.Equal(stripped, want) {
+		t.Errorf("StrippedData got %x want %x", stripped, want)
+	}
+
+	text := PDUPart{
+		Header: map[string]string{"Content-Transfer-Encoding": "base64", "Character-Set": "UTF-8"},
+		Data:   []byte(base64.StdEncoding.EncodeToString([]byte("café"))),
+	}
+	if got, err := text.Text(); err != nil || got != "café" {
+		t.Errorf("Text got %q, %v", got, err)
+	}
+}
+
+func TestContentTypeParams(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x84, 0xa3, // application/vnd.wap.multipart.mixed
+		0x01,
+		0x19, 0x01,
+		0x11, 0x9e, // image/jpeg
+		0x93, 0x04, 0x65, 0x53, 0xf1, 0x00, // creation-date=1700000000
+		0x96, 0x02, 0x08, 0x00, // size=2048
+		0x9b, 'h', 'i', 0x00, // comment=hi
+		0x90, 0x00, // secure
+		0x8e, 'a', '.', 'j', 'p', 'g', 0x00, // Content-Location: a.jpg
+		'x',
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	part := msg.Parts[0]
+
+	want := map[WellKnownParam]string{
+		SizeParam:    "2048",
+		CommentParam: "hi",
+		SecureParam:  "",
+	}
+	if diff := cmp.Diff(want, part.Params); diff != "" {
+		t.Errorf("params mismatch (-want +got):\n%s", diff)
+	}
+	if got := part.Header["Creation-Date"]; got != "2023-11-14T22:13:20Z" {
+		t.Errorf("creation date got %q", got)
+	}
+	if got := part.Header["Content-Location"]; got != "a.jpg" {
+		t.Errorf("parameters overran into Content-Location, got %q", got)
+	}
+
+	checkRoundTrip(t, msg)
+}
@@ -0,0 +1,86 @@
+package mms
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/psanford/gsm/mms/smil"
+)
+
+// ErrNoPresentation is returned by Presentation for a message without
+// a SMIL part.
+var ErrNoPresentation = errors.New("message has no smil presentation")
+
+// Presentation parses the message's SMIL presentation part. The part
+// is the one named by the Start parameter of a multipart/related
+// Content-Type, matched against each part's Content-ID or
+// Content-Location. Without a Start parameter, or if it names no part,
+// the first application/smil part is used.
+func (m *Message) Presentation() (*smil.SMIL, error) {
+	part, ok := m.startPart()
+	if !ok || !part.IsPresentation() {
+		part, ok = m.PresentationPart()
+	}
+	if !ok {
+		return nil, ErrNoPresentation
+	}
+	return smil.ParseSMIL(part.Data)
+}
+
+// startPart returns the part named by the Start content type
+// parameter.
+func (m *Message) startPart() (*PDUPart, bool) {
+	start := m.ContentTypeParams[StartParam]
+	if start == "" {
+		return nil, false
+	}
+	if p, ok := m.PartByContentID(start); ok {
+		return p, true
+	}
+	return m.PartByContentLocation(start)
+}
+
+// PartByContentID returns the part whose Content-ID is id. A leading
+// "cid:", as used by SMIL src attributes, and the angle brackets and
+// quote around a Content-ID are ignored on both sides.
+func (m *Message) PartByContentID(id string) (*PDUPart, bool) {
+	id = contentRef(id)
+	if id == "" {
+		return nil, false
+	}
+	for i := range m.Parts {
+		if contentRef(m.Parts[i].Header[ContentIDPartHeader.String()]) == id {
+			return &m.Parts[i], true
+		}
+	}
+	return nil, false
+}
+
+// PartByContentLocation returns the part whose Content-Location is loc.
+func (m *Message) PartByContentLocation(loc string) (*PDUPart, bool) {
+	loc = contentRef(loc)
+	if loc == "" {
+		return nil, false
+	}
+	for i := range m.Parts {
+		if contentRef(m.Parts[i].Header[ContentLocationPartHeader.String()]) == loc {
+			return &m.Parts[i], true
+		}
+	}
+	return nil, false
+}
+
+// contentRef normalises a Content-ID, Content-Location or reference to
+// one for comparison. A "cid:" URL is unescaped, and the quote and
+// angle brackets that devices add inconsistently are removed.
+func contentRef(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 4 && strings.EqualFold(s[:4], "cid:") {
+		s = s[4:]
+		if u, err := url.PathUnescape(s); err == nil {
+			s = u
+		}
+	}
+	return strings.Trim(s, "\"<>")
+}
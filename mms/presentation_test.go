@@ -0,0 +1,143 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPresentation(t *testing.T) {
+	smilPart := func(id, src string) PDUPart {
+		return PDUPart{
+			Header:      map[string]string{"Content-ID": id},
+			ContentType: "application/smil",
+			Data:        []byte(`<smil><body><par dur="5000ms"><img src="` + src + `"/></par></body></smil>`),
+		}
+	}
+
+	typ := MRetrieveConf
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			MessageType: {&typ},
+			MMSVersion:  {hs("1.2")},
+			ContentType: {hs("application/vnd.wap.multipart.related")},
+		},
+		ContentTypeParams: map[WellKnownParam]string{
+			StartParam: "<smil2>",
+			TypeParam:  "application/smil",
+		},
+		Parts: []PDUPart{
+			smilPart("<smil1>", "first.jpg"),
+			smilPart("<smil2>", "second.jpg"),
+			{
+				Header:      map[string]string{},
+				ContentType: "image/jpeg",
+				Data:        []byte{0xff, 0xd8},
+			},
+		},
+	}
+
+	packet, err := Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start := got.ContentTypeParams[StartParam]; start != "<smil2>" {
+		t.Fatalf("start param got %q", start)
+	}
+
+	s, err := got.Presentation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Slides) != 1 || len(s.Slides[0].Media) != 1 || s.Slides[0].Media[0].Src != "second.jpg" {
+		t.Errorf("got slides %+v, want the presentation named by start", s.Slides)
+	}
+
+	// Without a Start parameter the first SMIL part is used.
+	got.ContentTypeParams = nil
+	s, err = got.Presentation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := s.Slides[0].Media[0].Src; src != "first.jpg" {
+		t.Errorf("got src %q, want first.jpg", src)
+	}
+
+	got.Parts = got.Parts[2:]
+	if _, err := got.Presentation(); err != ErrNoPresentation {
+		t.Errorf("got err %v, want ErrNoPresentation", err)
+	}
+}
+
+func TestPartByContentRef(t *testing.T) {
+	msg := &Message{
+		Parts: []PDUPart{
+			{Header: map[string]string{"Content-ID": "\"<photo 1>", "Content-Location": "photo1.jpg"}},
+			{Header: map[string]string{"Content-ID": "text0", "Content-Location": "text0.txt"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		id   string
+		want int
+	}{
+		{"cid:photo%201", 0},
+		{"<photo 1>", 0},
+		{"CID:text0", 1},
+		{"<text0>", 1},
+		{"photo1.jpg", -1},
+		{"", -1},
+	} {
+		p, ok := msg.PartByContentID(tc.id)
+		if tc.want < 0 {
+			if ok {
+				t.Errorf("PartByContentID(%q) found %v", tc.id, p.Header)
+			}
+			continue
+		}
+		if !ok || p != &msg.Parts[tc.want] {
+			t.Errorf("PartByContentID(%q) got %v, want part %d", tc.id, p, tc.want)
+		}
+	}
+
+	if p, ok := msg.PartByContentLocation("text0.txt"); !ok || p != &msg.Parts[1] {
+		t.Errorf("PartByContentLocation(text0.txt) got %v", p)
+	}
+	if _, ok := msg.PartByContentLocation("missing.txt"); ok {
+		t.Errorf("PartByContentLocation(missing.txt) found a part")
+	}
+}
+
+func TestMessageContentTypeParams(t *testing.T) {
+	packet := []byte{0x8c, 0x84} // m-retrieve-conf
+	ct := []byte{0xb3}           // application/vnd.wap.multipart.related
+	ct = append(ct, 0x99)
+	ct = append(ct, "<smil>\x00"...) // start
+	ct = append(ct, 0x89)
+	ct = append(ct, "application/smil\x00"...) // type
+	packet = append(packet, 0x84, byte(len(ct)))
+	packet = append(packet, ct...)
+	packet = append(packet, 0x00) // no parts
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[WellKnownParam]string{
+		StartParam: "<smil>",
+		TypeParam:  "application/smil",
+	}
+	if diff := cmp.Diff(want, msg.ContentTypeParams); diff != "" {
+		t.Errorf("content type params mismatch (-want +got):\n%s", diff)
+	}
+	if got := msg.ContentTypeParam(StartParam); got != "<smil>" {
+		t.Errorf("ContentTypeParam(StartParam) got %q", got)
+	}
+	if got := msg.ContentTypeParam(NameParam); got != "" {
+		t.Errorf("ContentTypeParam(NameParam) got %q", got)
+	}
+}
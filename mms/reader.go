@@ -0,0 +1,86 @@
+package mms
+
+import (
+	"bytes"
+	"io"
+)
+
+// byteReader is the input a decoder reads from: a *bufio.Reader for
+// streams, or a *sliceReader for input already in memory.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+	Peek(n int) ([]byte, error)
+	ReadBytes(delim byte) ([]byte, error)
+	Discard(n int) (int, error)
+}
+
+// sliceReader reads from a byte slice. Unlike a bufio.Reader over a
+// bytes.Reader it needs no buffer of its own, and its read position
+// is known directly.
+type sliceReader struct {
+	b   []byte
+	off int
+}
+
+func (r *sliceReader) Read(p []byte) (int, error) {
+	if r.off >= len(r.b) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.b[r.off:])
+	r.off += n
+	return n, nil
+}
+
+func (r *sliceReader) ReadByte() (byte, error) {
+	if r.off >= len(r.b) {
+		return 0, io.EOF
+	}
+	c := r.b[r.off]
+	r.off++
+	return c, nil
+}
+
+// Peek returns the next n bytes without consuming them. Like
+// bufio.Reader.Peek, it returns io.EOF along with any shorter
+// remainder. The result aliases the input.
+func (r *sliceReader) Peek(n int) ([]byte, error) {
+	rest := r.b[r.off:]
+	if n > len(rest) {
+		return rest, io.EOF
+	}
+	return rest[:n], nil
+}
+
+// ReadBytes reads up to and including delim, returning a copy.
+func (r *sliceReader) ReadBytes(delim byte) ([]byte, error) {
+	rest := r.b[r.off:]
+	i := bytes.IndexByte(rest, delim)
+	if i < 0 {
+		r.off = len(r.b)
+		return append([]byte(nil), rest...), io.EOF
+	}
+	r.off += i + 1
+	return append([]byte(nil), rest[:i+1]...), nil
+}
+
+func (r *sliceReader) Discard(n int) (int, error) {
+	if rest := len(r.b) - r.off; n > rest {
+		r.off = len(r.b)
+		return rest, io.EOF
+	}
+	r.off += n
+	return n, nil
+}
+
+// next consumes and returns the next n bytes without copying them, or
+// io.ErrUnexpectedEOF if fewer remain.
+func (r *sliceReader) next(n int) ([]byte, error) {
+	if n > len(r.b)-r.off {
+		r.off = len(r.b)
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := r.b[r.off : r.off+n : r.off+n]
+	r.off += n
+	return out, nil
+}
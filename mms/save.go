@@ -0,0 +1,124 @@
+package mms
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveParts writes the body of each part, decoded as by WriteTo, to a
+// file in dir and returns the paths written, in part order.
+//
+// Files are named after the part's FileName with any directory
+// components and unsafe characters removed. A part without a usable
+// FileName is named after its Content-ID, or "part" and its index,
+// with an extension for its content type. Names already used by an
+// earlier part get a numeric suffix. Existing files are overwritten.
+func (m *Message) SaveParts(dir string) ([]string, error) {
+	used := make(map[string]bool)
+	paths := make([]string, 0, len(m.Parts))
+	for i := range m.Parts {
+		p := &m.Parts[i]
+
+		name := uniqueName(partFileName(p, i), used)
+		path := filepath.Join(dir, name)
+		if err := savePart(p, path); err != nil {
+			return paths, fmt.Errorf("save part %d err: %w", i, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func savePart(p *PDUPart, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := p.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// partFileName returns the file name to save part i under, before
+// duplicates are resolved.
+func partFileName(p *PDUPart, i int) string {
+	if name := sanitizeFileName(p.FileName); name != "" {
+		return name
+	}
+	base := sanitizeFileName(contentRef(p.Header[ContentIDPartHeader.String()]))
+	if base == "" {
+		base = fmt.Sprintf("part%d", i)
+	}
+	if filepath.Ext(base) == "" {
+		base += extensionForType(p.ContentType)
+	}
+	return base
+}
+
+// sanitizeFileName reduces name to a single path element that is safe
+// to create, or "" if nothing usable remains.
+func sanitizeFileName(name string) string {
+	// Treat both separators as directories, whatever the platform.
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, r == 0x7f:
+			return -1
+		case strings.ContainsRune(`:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	// Leading dots would make the file hidden, or name "." or "..".
+	return strings.TrimLeft(strings.TrimSpace(name), ".")
+}
+
+// uniqueName returns name, or name with a numeric suffix before its
+// extension if it is already in used, and records the result.
+func uniqueName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	out := name
+	for n := 1; used[strings.ToLower(out)]; n++ {
+		out = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	used[strings.ToLower(out)] = true
+	return out
+}
+
+// partExtensions holds file extensions for media types common in MMS,
+// which the mime package either lacks or has several choices for.
+var partExtensions = map[string]string{
+	"application/smil": ".smil",
+	"audio/amr":        ".amr",
+	"audio/amr-wb":     ".awb",
+	"image/gif":        ".gif",
+	"image/jpeg":       ".jpg",
+	"image/jpg":        ".jpg",
+	"image/png":        ".png",
+	"text/plain":       ".txt",
+	"text/x-vcard":     ".vcf",
+	"text/x-vcalendar": ".vcs",
+	"video/3gpp":       ".3gp",
+	"video/mp4":        ".mp4",
+}
+
+// extensionForType returns a file extension for contentType, falling
+// back to ".bin" for unknown types.
+func extensionForType(contentType string) string {
+	mt := mediaType(contentType)
+	if ext, ok := partExtensions[mt]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mt); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,70 @@
+package mms
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveParts(t *testing.T) {
+	msg := &Message{
+		Parts: []PDUPart{
+			{Header: map[string]string{}, FileName: "../../etc/passwd", Data: []byte("one")},
+			{Header: map[string]string{}, FileName: "a.txt", Data: []byte("two")},
+			{Header: map[string]string{}, FileName: "A.txt", Data: []byte("three")},
+			{Header: map[string]string{"Content-ID": "<img1>"}, ContentType: "image/jpeg", Data: []byte("four")},
+			{
+				Header:      map[string]string{"Content-Transfer-Encoding": "base64"},
+				ContentType: "text/plain; charset=utf-8",
+				Data:        []byte("Zml2ZQ=="),
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	paths, err := msg.SaveParts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, p := range paths {
+		if filepath.Dir(p) != dir {
+			t.Errorf("%s written outside %s", p, dir)
+		}
+		names = append(names, filepath.Base(p))
+	}
+	want := []string{"passwd", "a.txt", "A-1.txt", "img1.jpg", "part4.txt"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("file names mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := os.ReadFile(paths[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "five" {
+		t.Errorf("got %q, want the transfer decoded body", got)
+	}
+}
+
+func TestPartWriteTo(t *testing.T) {
+	part := &PDUPart{
+		Header: map[string]string{"Content-Transfer-Encoding": "base64"},
+		Data:   []byte("aGVsbG8="),
+	}
+
+	var w io.WriterTo = part
+	var buf bytes.Buffer
+	n, err := w.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || buf.String() != "hello" {
+		t.Errorf("got %d bytes %q", n, buf.String())
+	}
+}
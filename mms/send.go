@@ -0,0 +1,216 @@
+package mms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// smilContentID is the Content-ID given to the presentation part of a
+// built send request.
+const smilContentID = "<smil>"
+
+// SendRequestBuilder constructs an m-send-req PDU. Create one with
+// NewSendRequest, set its fields, then call Build. The first error
+// from any setter is returned by Build.
+type SendRequestBuilder struct {
+	transactionID string
+	from          string
+	to            []string
+	subject       string
+	smil          []byte
+	parts         []PDUPart
+	err           error
+}
+
+// NewSendRequest returns a builder for an MMS 1.2 m-send-req with a
+// random Transaction-ID and, until SetFrom is called, the
+// insert-address-token as its sender.
+func NewSendRequest() *SendRequestBuilder {
+	return &SendRequestBuilder{}
+}
+
+// SetTransactionID sets the X-Mms-Transaction-Id in place of the
+// random one Build would otherwise generate.
+func (b *SendRequestBuilder) SetTransactionID(id string) *SendRequestBuilder {
+	b.transactionID = id
+	return b
+}
+
+// SetFrom sets the sender address. A bare phone number is given the
+// "/TYPE=PLMN" suffix.
+func (b *SendRequestBuilder) SetFrom(addr string) *SendRequestBuilder {
+	b.from = sendAddress(addr)
+	return b
+}
+
+// AddTo adds a recipient address. A bare phone number is given the
+// "/TYPE=PLMN" suffix.
+func (b *SendRequestBuilder) AddTo(addr string) *SendRequestBuilder {
+	if addr == "" {
+		b.setErr(errors.New("empty To address"))
+		return b
+	}
+	b.to = append(b.to, sendAddress(addr))
+	return b
+}
+
+// SetSubject sets the message subject.
+func (b *SendRequestBuilder) SetSubject(subject string) *SendRequestBuilder {
+	b.subject = subject
+	return b
+}
+
+// AddPart adds a part with the given content type and data. filename
+// is used as the part's name, Content-Location and Content-ID, so
+// that a SMIL presentation can refer to it; if empty a name is
+// generated. Text parts without a charset parameter are sent as UTF-8.
+func (b *SendRequestBuilder) AddPart(contentType, filename string, data []byte) *SendRequestBuilder {
+	if contentType == "" {
+		b.setErr(fmt.Errorf("part %d has no content type", len(b.parts)))
+		return b
+	}
+	if filename == "" {
+		filename = fmt.Sprintf("part%d", len(b.parts))
+	}
+
+	part := PDUPart{
+		Header: map[string]string{
+			"Name":                             filename,
+			ContentLocationPartHeader.String(): filename,
+			ContentIDPartHeader.String():       "<" + filename + ">",
+		},
+		ContentType: contentType,
+		Data:        data,
+	}
+	if strings.HasPrefix(strings.ToLower(contentType), "text/") {
+		part.Header["Character-Set"] = "utf-8"
+	}
+	b.parts = append(b.parts, part)
+	return b
+}
+
+// SetSMIL sets the SMIL presentation of the message. It is sent as the
+// first part and the message becomes multipart/related with Start and
+// Type parameters naming it. Without a presentation the message is
+// sent as multipart/mixed.
+func (b *SendRequestBuilder) SetSMIL(smil []byte) *SendRequestBuilder {
+	b.smil = smil
+	return b
+}
+
+func (b *SendRequestBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build encodes the send request.
+func (b *SendRequestBuilder) Build() ([]byte, error) {
+	msg, err := b.message()
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(msg)
+}
+
+// message returns the Message that Build encodes.
+func (b *SendRequestBuilder) message() (*Message, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.to) == 0 {
+		return nil, errors.New("send request has no recipients")
+	}
+	if len(b.parts) == 0 && b.smil == nil {
+		return nil, errors.New("send request has no parts")
+	}
+
+	tid := b.transactionID
+	if tid == "" {
+		var err error
+		if tid, err = newTransactionID(); err != nil {
+			return nil, err
+		}
+	}
+	from := HeaderFrom{Address: b.from, Insert: b.from == ""}
+
+	msg := Message{
+		Header: make(map[MMSField][]HeaderField),
+	}
+	add := func(f MMSField, s string) {
+		hs := HeaderString(s)
+		msg.Header[f] = append(msg.Header[f], &hs)
+	}
+
+	typ := MSendReq
+	msg.Header[MessageType] = []HeaderField{&typ}
+	add(TransactionID, tid)
+	add(MMSVersion, defaultVersion)
+	msg.Header[From] = []HeaderField{&from}
+	for _, to := range b.to {
+		add(To, to)
+	}
+	if b.subject != "" {
+		add(Subject, b.subject)
+	}
+
+	if b.smil == nil {
+		add(ContentType, "application/vnd.wap.multipart.mixed")
+		msg.Parts = append(msg.Parts, b.parts...)
+		return &msg, nil
+	}
+
+	add(ContentType, "application/vnd.wap.multipart.related")
+	msg.ContentTypeParams = map[WellKnownParam]string{
+		StartParam: smilContentID,
+		TypeParam:  "application/smil",
+	}
+	msg.Parts = append(msg.Parts, PDUPart{
+		Header: map[string]string{
+			"Name":                             "smil.xml",
+			ContentLocationPartHeader.String(): "smil.xml",
+			ContentIDPartHeader.String():       smilContentID,
+		},
+		ContentType: "application/smil",
+		Data:        b.smil,
+	})
+	msg.Parts = append(msg.Parts, b.parts...)
+	return &msg, nil
+}
+
+// newTransactionID returns a random X-Mms-Transaction-Id.
+func newTransactionID() (string, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate transaction id err: %w", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+// sendAddress returns addr in its encoded form, adding the PLMN type
+// to a bare phone number.
+func sendAddress(addr string) string {
+	a := ParseAddress(addr)
+	if a.Type == "" && isPhoneNumber(a.Value) {
+		a.Type = PLMNAddress
+	}
+	return a.String()
+}
+
+// isPhoneNumber reports whether s is a global-phone-number: an
+// optional "+" followed by digits.
+func isPhoneNumber(s string) bool {
+	s = strings.TrimPrefix(s, "+")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSendRequestBuilder(t *testing.T) {
+	smilDoc := []byte(`<smil><body><par><img src="cat.jpg"/><text src="hello.txt"/></par></body></smil>`)
+
+	packet, err := NewSendRequest().
+		SetTransactionID("tx-1").
+		SetFrom("+15551231234").
+		AddTo("+15550001111").
+		AddTo("bob@example.com").
+		SetSubject("café").
+		SetSMIL(smilDoc).
+		AddPart("image/jpeg", "cat.jpg", []byte{0xff, 0xd8, 0xff, 0xd9}).
+		AddPart("text/plain", "hello.txt", []byte("hello")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if packet[0] != 0x8c || packet[1] != 0x80 {
+		t.Fatalf("packet does not start with m-send-req: %x", packet[:2])
+	}
+
+	msg, err := Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHeader := map[string][]string{
+		"Message-Type":   {"m-send-req"},
+		"Transaction-ID": {"tx-1"},
+		"MMS-Version":    {"1.2"},
+		"From":           {"+15551231234/TYPE=PLMN"},
+		"To":             {"+15550001111/TYPE=PLMN", "bob@example.com"},
+		"Subject":        {"café"},
+		"Content-Type":   {"application/vnd.wap.multipart.related"},
+	}
+	if diff := cmp.Diff(wantHeader, headerStrings(msg)); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	wantParams := map[WellKnownParam]string{
+		StartParam: "<smil>",
+		TypeParam:  "application/smil",
+	}
+	if diff := cmp.Diff(wantParams, msg.ContentTypeParams); diff != "" {
+		t.Errorf("content type params mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(msg.Parts) != 3 {
+		t.Fatalf("got %d parts", len(msg.Parts))
+	}
+	pres, err := msg.Presentation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range pres.Slides[0].Media {
+		if _, ok := msg.PartByContentLocation(m.Src); !ok {
+			t.Errorf("presentation src %q names no part", m.Src)
+		}
+	}
+	text, err := msg.Parts[2].Text()
+	if err != nil || text != "hello" {
+		t.Errorf("text part got %q, %v", text, err)
+	}
+}
+
+func TestSendRequestBuilderErrors(t *testing.T) {
+	for name, b := range map[string]*SendRequestBuilder{
+		"no recipients": NewSendRequest().AddPart("text/plain", "", []byte("hi")),
+		"no parts":      NewSendRequest().AddTo("+15550001111"),
+		"empty to":      NewSendRequest().AddTo("").AddPart("text/plain", "", []byte("hi")),
+		"no type":       NewSendRequest().AddTo("+15550001111").AddPart("", "a.bin", nil),
+	} {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	msg, err := NewSendRequest().AddTo("+15550001111").AddPart("text/plain", "", []byte("hi")).message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.From(); got != "<insert-address-token>" {
+		t.Errorf("default from got %q", got)
+	}
+	if got := msg.ContentType(); got != "application/vnd.wap.multipart.mixed" {
+		t.Errorf("content type without smil got %q", got)
+	}
+	if len(msg.stringField(TransactionID)) != 16 {
+		t.Errorf("generated transaction id %q", msg.stringField(TransactionID))
+	}
+}
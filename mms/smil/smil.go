@@ -0,0 +1,189 @@
+// Package smil parses the SMIL presentation part of an MMS message.
+//
+// MMS uses a small subset of SMIL, described by the OMA MMS
+// Conformance Document: a head holding the root-layout and regions,
+// and a body of par elements, one per slide, each holding at most one
+// image or video, one text and one audio element.
+package smil
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SMIL is a parsed presentation.
+type SMIL struct {
+	RootLayout RootLayout
+	Regions    []Region
+
+	// Slides holds the slides in presentation order.
+	Slides []Slide
+}
+
+// RootLayout is the root-layout element, sizing the presentation.
+type RootLayout struct {
+	Width           string
+	Height          string
+	BackgroundColor string
+}
+
+// Region is a region element, a rectangle media is placed in.
+type Region struct {
+	ID     string
+	Left   string
+	Top    string
+	Width  string
+	Height string
+	Fit    string
+}
+
+// Slide is a par element, or a media element played on its own.
+type Slide struct {
+	// Duration is the dur attribute of the par, such as "5000ms".
+	Duration string
+	Media    []Media
+}
+
+// Media is a media object element such as img or text.
+type Media struct {
+	// Kind is the element name: img, text, audio, video or ref.
+	Kind string
+
+	// Src references the part holding the media, usually by its
+	// Content-Location or a "cid:" Content-ID URL.
+	Src string
+
+	Region string
+	Alt    string
+	Begin  string
+	End    string
+}
+
+// node is a generic element, used to walk the document in order.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []node     `xml:",any"`
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) child(name string) *node {
+	for i := range n.Nodes {
+		if strings.EqualFold(n.Nodes[i].XMLName.Local, name) {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// ParseSMIL parses a SMIL document. Element names are matched without
+// regard to namespace or case, as handsets are inconsistent about both.
+// Slides are taken from the par elements of the body in document
+// order, descending into seq elements. A media element outside any
+// par is a slide of its own.
+func ParseSMIL(data []byte) (*SMIL, error) {
+	// Documents from some handsets carry a trailing NUL.
+	data = bytes.TrimRight(data, "\x00")
+
+	var root node
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	// Declared charsets other than UTF-8 are ASCII-compatible in
+	// practice, which is all the markup needs.
+	dec.CharsetReader = func(charset string, r io.Reader) (io.Reader, error) {
+		return r, nil
+	}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse smil err: %w", err)
+	}
+	if !strings.EqualFold(root.XMLName.Local, "smil") {
+		return nil, fmt.Errorf("root element is %q, not smil", root.XMLName.Local)
+	}
+
+	var s SMIL
+	if head := root.child("head"); head != nil {
+		if layout := head.child("layout"); layout != nil {
+			for _, n := range layout.Nodes {
+				switch strings.ToLower(n.XMLName.Local) {
+				case "root-layout":
+					s.RootLayout = RootLayout{
+						Width:           n.attr("width"),
+						Height:          n.attr("height"),
+						BackgroundColor: n.attr("backgroundColor"),
+					}
+				case "region":
+					s.Regions = append(s.Regions, Region{
+						ID:     n.attr("id"),
+						Left:   n.attr("left"),
+						Top:    n.attr("top"),
+						Width:  n.attr("width"),
+						Height: n.attr("height"),
+						Fit:    n.attr("fit"),
+					})
+				}
+			}
+		}
+	}
+
+	if body := root.child("body"); body != nil {
+		s.Slides = appendSlides(s.Slides, body)
+	}
+
+	return &s, nil
+}
+
+// appendSlides appends the slides held by a body or seq element.
+func appendSlides(slides []Slide, n *node) []Slide {
+	for i := range n.Nodes {
+		c := &n.Nodes[i]
+		switch strings.ToLower(c.XMLName.Local) {
+		case "par":
+			slide := Slide{Duration: c.attr("dur")}
+			for j := range c.Nodes {
+				if m, ok := media(&c.Nodes[j]); ok {
+					slide.Media = append(slide.Media, m)
+				}
+			}
+			slides = append(slides, slide)
+		case "seq":
+			slides = appendSlides(slides, c)
+		default:
+			if m, ok := media(c); ok {
+				slides = append(slides, Slide{
+					Duration: c.attr("dur"),
+					Media:    []Media{m},
+				})
+			}
+		}
+	}
+	return slides
+}
+
+// media returns n as a Media if it is a media object element.
+func media(n *node) (Media, bool) {
+	kind := strings.ToLower(n.XMLName.Local)
+	switch kind {
+	case "img", "text", "audio", "video", "ref":
+	default:
+		return Media{}, false
+	}
+	return Media{
+		Kind:   kind,
+		Src:    n.attr("src"),
+		Region: n.attr("region"),
+		Alt:    n.attr("alt"),
+		Begin:  n.attr("begin"),
+		End:    n.attr("end"),
+	}, true
+}
@@ -0,0 +1,66 @@
+package smil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSMIL(t *testing.T) {
+	doc := `<smil>
+<head>
+<layout>
+<root-layout width="320px" height="480px"/>
+<region id="Image" left="0" top="0" width="320px" height="320px" fit="meet"/>
+<region id="Text" left="0" top="320" width="320px" height="160px" fit="meet"/>
+</layout>
+</head>
+<body>
+<par dur="5000ms">
+<img src="cid:photo.jpg" region="Image"/>
+<text src="text0.txt" region="Text"/>
+</par>
+<seq>
+<par dur="3000ms"><audio src="clip.amr"/></par>
+</seq>
+<video src="movie.3gp" region="Image" dur="10s"/>
+</body>
+</smil>` + "\x00"
+
+	got, err := ParseSMIL([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &SMIL{
+		RootLayout: RootLayout{Width: "320px", Height: "480px"},
+		Regions: []Region{
+			{ID: "Image", Left: "0", Top: "0", Width: "320px", Height: "320px", Fit: "meet"},
+			{ID: "Text", Left: "0", Top: "320", Width: "320px", Height: "160px", Fit: "meet"},
+		},
+		Slides: []Slide{
+			{
+				Duration: "5000ms",
+				Media: []Media{
+					{Kind: "img", Src: "cid:photo.jpg", Region: "Image"},
+					{Kind: "text", Src: "text0.txt", Region: "Text"},
+				},
+			},
+			{
+				Duration: "3000ms",
+				Media:    []Media{{Kind: "audio", Src: "clip.amr"}},
+			},
+			{
+				Duration: "10s",
+				Media:    []Media{{Kind: "video", Src: "movie.3gp", Region: "Image"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("smil mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseSMIL([]byte("<html></html>")); err == nil {
+		t.Errorf("expected error for non-smil document")
+	}
+}
@@ -0,0 +1,44 @@
+package mms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// String returns a human readable dump of the message: one header per
+// line in the order of Fields, which Marshal writes them in, then any
+// application headers, followed by a one line summary of each part.
+func (m *Message) String() string {
+	var b strings.Builder
+
+	for _, fv := range m.Fields() {
+		fmt.Fprintf(&b, "%s: %s\n", fv.Field, fv.Value)
+	}
+
+	names := make([]string, 0, len(m.AppHeaders))
+	for name := range m.AppHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %s\n", name, m.AppHeaders[name])
+	}
+
+	for _, h := range m.UnknownHeaders {
+		fmt.Fprintf(&b, "%s: %x\n", h.Field, h.Raw)
+	}
+
+	if len(m.Parts) > 0 {
+		b.WriteString("\n")
+	}
+	for i, p := range m.Parts {
+		fmt.Fprintf(&b, "Part %d: %s", i, p.ContentType)
+		if p.FileName != "" {
+			fmt.Fprintf(&b, " %q", p.FileName)
+		}
+		fmt.Fprintf(&b, " (%d bytes)\n", len(p.Data))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMessageString(t *testing.T) {
+	typ := MRetrieveConf
+	msg := &Message{
+		Header: map[MMSField][]HeaderField{
+			ContentType:   {hs("application/vnd.wap.multipart.mixed")},
+			Subject:       {hs("hello")},
+			To:            {hs("a@example.com"), hs("b@example.com")},
+			MessageType:   {&typ},
+			TransactionID: {hs("tx")},
+		},
+		AppHeaders: map[string]string{"X-Carrier": "example"},
+		Parts: []PDUPart{
+			{ContentType: "application/smil", Data: make([]byte, 120)},
+			{ContentType: "image/jpeg", FileName: "a.jpg", Data: make([]byte, 2048)},
+		},
+	}
+
+	want := `Message-Type: m-retrieve-conf
+Transaction-ID: tx
+Subject: hello
+To: a@example.com
+To: b@example.com
+Content-Type: application/vnd.wap.multipart.mixed
+X-Carrier: example
+
+Part 0: application/smil (120 bytes)
+Part 1: image/jpeg "a.jpg" (2048 bytes)
+`
+	if diff := cmp.Diff(want, msg.String()); diff != "" {
+		t.Errorf("String mismatch (-want +got):\n%s", diff)
+	}
+
+	msg.FieldOrder = []MMSField{Subject, MessageType, To, TransactionID}
+	want = `Subject: hello
+Message-Type: m-retrieve-conf
+To: a@example.com
+Transaction-ID: tx
+To: b@example.com
+Content-Type: application/vnd.wap.multipart.mixed
+X-Carrier: example
+
+Part 0: application/smil (120 bytes)
+Part 1: image/jpeg "a.jpg" (2048 bytes)
+`
+	if diff := cmp.Diff(want, msg.String()); diff != "" {
+		t.Errorf("String with FieldOrder mismatch (-want +got):\n%s", diff)
+	}
+}
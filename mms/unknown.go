@@ -0,0 +1,104 @@
+package mms
+
+import (
+	"fmt"
+	"io"
+)
+
+// An UnknownHeader is a header field the decoder has no grammar for,
+// captured in lenient mode.
+type UnknownHeader struct {
+	Field MMSField
+	// Raw is the field's value exactly as encoded, including any
+	// length prefix or string terminator.
+	Raw []byte
+	// Encoding is the value encoding that was assumed to find the
+	// end of the value.
+	Encoding ValueEncoding
+}
+
+// ValueEncoding identifies the WSP value encoding assumed for an
+// unknown header field.
+type ValueEncoding int
+
+const (
+	// TextValue is a NUL terminated Text-string, assumed when the
+	// value starts with an octet in 32-127.
+	TextValue ValueEncoding = iota
+	// LengthPrefixedValue is a value preceded by a Value-length,
+	// assumed when the value starts with an octet in 0-31.
+	LengthPrefixedValue
+	// ShortIntegerValue is a single octet Short-integer, assumed
+	// when the value starts with an octet in 128-255.
+	ShortIntegerValue
+)
+
+func (e ValueEncoding) String() string {
+	switch e {
+	case TextValue:
+		return "text"
+	case LengthPrefixedValue:
+		return "length-prefixed"
+	case ShortIntegerValue:
+		return "short-integer"
+	default:
+		return fmt.Sprintf("UnknownValueEncoding<%d>", int(e))
+	}
+}
+
+// decodeUnknownValue reads the value of a field whose grammar is not
+// known. MMS header values carry no common length, so the end of the
+// value is guessed from its first octet, which is how every WSP value
+// encoding distinguishes itself:
+//
+//	Text-value    = <Octet 32-127> ... End-of-string
+//	Value-length  = <Octet 0-30> | <Octet 31> Uintvar-integer
+//	Short-integer = <Octet 128-255>
+//
+// A Long-integer starts with its Short-length, so it is skipped
+// correctly as a length-prefixed value. Values that use any other
+// encoding, such as a bare Uintvar-integer, are misread and
+// desynchronize the rest of the header.
+func (d *decoder) decodeUnknownValue() ([]byte, ValueEncoding, error) {
+	peekBuf, err := d.r.Peek(1)
+	if err != nil {
+		return nil, 0, err
+	}
+	b := peekBuf[0]
+
+	switch {
+	case b >= 128:
+		d.r.ReadByte()
+		return []byte{b}, ShortIntegerValue, nil
+	case b >= 32:
+		raw, err := d.r.ReadBytes(0)
+		if err != nil {
+			return nil, 0, err
+		}
+		return raw, TextValue, nil
+	}
+
+	prefixLen, valueLen := 1, int(b)
+	if b == 31 {
+		peekBuf, _ = d.r.Peek(6)
+		var n uint32
+		more := true
+		for prefixLen = 1; prefixLen < len(peekBuf) && more; prefixLen++ {
+			n = n<<7 | uint32(peekBuf[prefixLen]&0x7f)
+			more = peekBuf[prefixLen]&0x80 != 0
+		}
+		if more {
+			return nil, 0, fmt.Errorf("invalid var uint at pos:%d: %w", d.offset()+1, ErrInvalidValue)
+		}
+		valueLen = int(n)
+	}
+
+	if err := d.checkLength(uint64(prefixLen+valueLen), "value"); err != nil {
+		return nil, 0, err
+	}
+	raw := make([]byte, prefixLen+valueLen)
+	if _, err := io.ReadFull(d.r, raw); err != nil {
+		return nil, 0, err
+	}
+	return raw, LengthPrefixedValue, nil
+}
@@ -0,0 +1,63 @@
+package mms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnknownHeaders(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0xc0, 'x', 'y', 0x00, // unknown text value
+		0xc1, 0x02, 0x01, 0x02, // unknown length prefixed value
+		0xc2, 0x1f, 0x01, 0xaa, // unknown uintvar length prefixed value
+		0xc3, 0x81, // unknown short integer
+		0x98, 't', 'x', 0x00, // Transaction-ID
+	}
+
+	if _, err := Unmarshal(packet); err == nil {
+		t.Fatal("expected strict decode to fail on unknown field")
+	}
+
+	msg, err := UnmarshalWithOptions(packet, DecodeOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []UnknownHeader{
+		{Field: 0x40, Raw: []byte{'x', 'y', 0x00}, Encoding: TextValue},
+		{Field: 0x41, Raw: []byte{0x02, 0x01, 0x02}, Encoding: LengthPrefixedValue},
+		{Field: 0x42, Raw: []byte{0x1f, 0x01, 0xaa}, Encoding: LengthPrefixedValue},
+		{Field: 0x43, Raw: []byte{0x81}, Encoding: ShortIntegerValue},
+	}
+	if diff := cmp.Diff(want, msg.UnknownHeaders); diff != "" {
+		t.Errorf("unknown headers mismatch (-want +got):\n%s", diff)
+	}
+	if tid := msg.Header[TransactionID]; len(tid) != 1 || tid[0].String() != "tx" {
+		t.Errorf("got Transaction-ID %v", tid)
+	}
+}
+
+func TestUnmarshalLenient(t *testing.T) {
+	packet := []byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0xc0, 0x04, 0x5f, 0x00, 0x00, 0x00, // unknown Long-integer value
+		0x98, 't', 'x', 0x00, // Transaction-ID
+		0x85, 0x01, // truncated Date
+	}
+
+	msg, err := UnmarshalLenient(packet)
+	if err == nil {
+		t.Fatal("expected error for truncated date")
+	}
+	if msg == nil {
+		t.Fatal("expected partial message")
+	}
+	if len(msg.UnknownHeaders) != 1 || msg.UnknownHeaders[0].Field != 0x40 {
+		t.Errorf("got unknown headers %+v", msg.UnknownHeaders)
+	}
+	if tid := msg.Header[TransactionID]; len(tid) != 1 || tid[0].String() != "tx" {
+		t.Errorf("got Transaction-ID %v", tid)
+	}
+}
@@ -0,0 +1,124 @@
+package mms
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// fieldVersion returns the MMS version that introduced the field code
+// f, based on the field assignment tables in OMA-MMS-ENC.
+func fieldVersion(f MMSField) (major, minor int, ok bool) {
+	switch {
+	case f >= 0x01 && f <= 0x18:
+		return 1, 0, true
+	case f >= 0x19 && f <= 0x21:
+		return 1, 1, true
+	case f >= 0x22 && f <= 0x33:
+		return 1, 2, true
+	case f >= 0x34 && f <= 0x3f:
+		return 1, 3, true
+	}
+	return 0, 0, false
+}
+
+// ValidateForVersion reports header fields in m that are not permitted
+// in the given MMS version, e.g. reply-charging fields in a 1.0
+// message. The returned error joins one error per offending field.
+func (m *Message) ValidateForVersion(major, minor int) error {
+	fields := make([]MMSField, 0, len(m.Header))
+	for f := range m.Header {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i] < fields[j]
+	})
+
+	var errs []error
+	for _, f := range fields {
+		fMajor, fMinor, ok := fieldVersion(f)
+		if !ok {
+			errs = append(errs, fmt.Errorf("field %s is not defined in any MMS version", f))
+			continue
+		}
+		if fMajor > major || (fMajor == major && fMinor > minor) {
+			errs = append(errs, fmt.Errorf("field %s requires MMS %d.%d, message is %d.%d", f, fMajor, fMinor, major, minor))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ErrMissingField is wrapped by the errors Validate returns for each
+// mandatory field a message lacks.
+var ErrMissingField = errors.New("missing mandatory field")
+
+// mandatoryFields lists the fields each message type must carry, from
+// the PDU descriptions of WAP-209 and OMA-MMS-ENC. Message-Type and
+// MMS-Version are mandatory in every PDU and aren't listed.
+var mandatoryFields = map[HeaderMessageType][]MMSField{
+	MSendReq:         {TransactionID, From, ContentType},
+	MSendConf:        {TransactionID, ResponseStatus},
+	MNotificationInd: {TransactionID, MessageClass, MessageSize, Expiry, ContentLocation},
+	MNotifyrespInd:   {TransactionID, StatusField},
+	MRetrieveConf:    {Date, ContentType},
+	MAcknowledgeInd:  {TransactionID},
+	MDeliveryInd:     {MessageID, To, Date, StatusField},
+	MReadRecInd:      {MessageID, To, From, ReadStatus},
+	MReadOrigInd:     {MessageID, To, From, Date, ReadStatus},
+	MForwardReq:      {TransactionID, From, ContentLocation},
+	MForwardConf:     {TransactionID, ResponseStatus},
+	MCancelReq:       {TransactionID, CancelID},
+	MCancelConf:      {TransactionID},
+	MMboxStoreReq:    {TransactionID, ContentLocation},
+	MMboxStoreConf:   {TransactionID},
+	MMboxUploadReq:   {TransactionID, ContentType},
+	MMboxUploadConf:  {TransactionID},
+	MMboxDeleteReq:   {TransactionID, ContentLocation},
+	MMboxDeleteConf:  {TransactionID},
+	MDeleteReq:       {TransactionID, ContentLocation},
+	MDeleteConf:      {TransactionID},
+}
+
+// requiresRecipient lists the message types that must have at least
+// one of To, Cc and Bcc.
+var requiresRecipient = map[HeaderMessageType]bool{
+	MSendReq:    true,
+	MForwardReq: true,
+}
+
+// Validate checks that m carries the fields its message type makes
+// mandatory: Message-Type and MMS-Version for every PDU, and for
+// example From, Transaction-ID and Content-Type for an m-send-req or
+// Content-Location, Message-Size and Expiry for an m-notification-ind.
+// The returned error joins one error wrapping ErrMissingField per
+// missing field. Without a Message-Type only MMS-Version can be
+// checked.
+func (m *Message) Validate() error {
+	var errs []error
+	missing := func(what string) {
+		errs = append(errs, fmt.Errorf("%w %s", ErrMissingField, what))
+	}
+
+	typ, ok := m.field(MessageType).(*HeaderMessageType)
+	if !ok {
+		missing(MessageType.String())
+	}
+	if len(m.Header[MMSVersion]) == 0 {
+		missing(MMSVersion.String())
+	}
+	if !ok {
+		return errors.Join(errs...)
+	}
+
+	for _, f := range mandatoryFields[*typ] {
+		if len(m.Header[f]) == 0 {
+			missing(fmt.Sprintf("%s for %s", f, typ))
+		}
+	}
+	if requiresRecipient[*typ] && len(m.Header[To])+len(m.Header[Cc])+len(m.Header[Bcc]) == 0 {
+		missing(fmt.Sprintf("recipient (To, Cc or Bcc) for %s", typ))
+	}
+
+	return errors.Join(errs...)
+}
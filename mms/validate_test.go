@@ -0,0 +1,86 @@
+package mms
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateForVersion(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0x8c, 0x84, // m-retrieve-conf
+		0x8d, 0x90, // 1.0
+		0xbc, 0x80, // X-Mms-Adaptation-Allowed: yes
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := msg.ValidateForVersion(1, 3); err != nil {
+		t.Fatalf("unexpected error for 1.3: %s", err)
+	}
+
+	err = msg.ValidateForVersion(1, 0)
+	if err == nil {
+		t.Fatal("expected error for 1.0")
+	}
+	if !strings.Contains(err.Error(), "Adaptation-Allowed requires MMS 1.3") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(err.Error(), "Message-Type") {
+		t.Fatalf("1.0 field flagged: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0x8c, 0x82, // m-notification-ind
+		0x98, 't', 0x00, // X-Mms-Transaction-Id: t
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x8a, 0x80, // X-Mms-Message-Class: personal
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = msg.Validate()
+	if !errors.Is(err, ErrMissingField) {
+		t.Fatalf("expected ErrMissingField, got %v", err)
+	}
+	for _, want := range []string{"Message-Size", "Expiry", "Content-Location"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't mention %s: %s", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Transaction-ID") {
+		t.Errorf("present field flagged: %s", err)
+	}
+
+	packet, err := NewNotificationInd("http://example.com/m", 1000, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err = Unmarshal(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.Validate(); err != nil {
+		t.Errorf("NewNotificationInd: %s", err)
+	}
+
+	typ := MSendReq
+	msg = &Message{Header: map[MMSField][]HeaderField{
+		MessageType: {&typ},
+	}}
+	err = msg.Validate()
+	for _, want := range []string{"MMS-Version", "Transaction-ID", "From", "Content-Type", "recipient"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("m-send-req error doesn't mention %s: %v", want, err)
+		}
+	}
+
+	if err := (&Message{}).Validate(); err == nil || !strings.Contains(err.Error(), "Message-Type") {
+		t.Errorf("expected missing Message-Type error, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package mms
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 type WellKnownParam int
 
@@ -36,6 +39,72 @@ const (
 	PathParam             WellKnownParam = 0x9d
 )
 
+// String returns the WSP name of the parameter. Deprecated codes share
+// the name of their replacement and unassigned codes are written in
+// hex, e.g. "0x84".
+func (p WellKnownParam) String() string {
+	switch currentParam(p) {
+	case QParam:
+		return "Q"
+	case CharsetParam:
+		return "Charset"
+	case LevelParam:
+		return "Level"
+	case TypeParam, CtMrTypeParam:
+		return "Type"
+	case NameParam, DepNameParam:
+		return "Name"
+	case FilenameParam, DepFilenameParam:
+		return "Filename"
+	case DifferencesParam:
+		return "Differences"
+	case PaddingParam:
+		return "Padding"
+	case StartParam, DepStartParam:
+		return "Start"
+	case StartInfoParam:
+		return "Start-Info"
+	case CommentParam:
+		return "Comment"
+	case DomainParam:
+		return "Domain"
+	case MaxAgeParam:
+		return "Max-Age"
+	case PathParam:
+		return "Path"
+	case SecureParam:
+		return "Secure"
+	case SecParam:
+		return "SEC"
+	case MacParam:
+		return "MAC"
+	case CreationDateParam:
+		return "Creation-Date"
+	case ModificationDateParam:
+		return "Modification-Date"
+	case ReadDateParam:
+		return "Read-Date"
+	case SizeParam:
+		return "Size"
+	default:
+		return fmt.Sprintf("0x%x", int(p))
+	}
+}
+
+// paramByName returns the parameter whose String is name, preferring
+// current codes over deprecated ones.
+func paramByName(name string) (WellKnownParam, bool) {
+	for p := PathParam; p >= QParam; p-- {
+		if p.String() == name {
+			return p, true
+		}
+	}
+	if v, err := strconv.ParseUint(name, 0, 8); err == nil && v >= 0x80 {
+		return WellKnownParam(v), true
+	}
+	return 0, false
+}
+
 type PartHeaderField int
 
 const (
@@ -0,0 +1,171 @@
+package sms
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/psanford/gsm/gsm7"
+)
+
+// mtiDeliver is the TP-MTI, in the low two bits of the first TPDU
+// octet, of an SMS-DELIVER.
+const mtiDeliver = 0x00
+
+// SMSDeliver is a decoded SMS-DELIVER, the TPDU that carries a short
+// message from the SMSC to the mobile.
+type SMSDeliver struct {
+	// SMSC is the service centre address that precedes the TPDU. Its
+	// Number is empty when the PDU has none.
+	SMSC Address
+
+	// Originator is the TP-OA of the sender.
+	Originator Address
+
+	// MoreMessages is set when the SMSC has more messages waiting,
+	// the inverse of TP-MMS.
+	MoreMessages bool
+	// StatusReport is TP-SRI, set when the sender asked for a status
+	// report.
+	StatusReport bool
+	// ReplyPath is TP-RP.
+	ReplyPath bool
+
+	// PID is the TP-PID protocol identifier.
+	PID byte
+	// DCS is the TP-DCS data coding scheme, which selects Alphabet.
+	DCS      byte
+	Alphabet Alphabet
+
+	// Timestamp is the TP-SCTS at which the SMSC received the message.
+	Timestamp time.Time
+
+	// UDH is the User-Data-Header, present when TP-UDHI is set.
+	UDH *UDH
+
+	// Data is the user data following the header. For the 7 bit
+	// alphabet it holds the packed septets, realigned to start at the
+	// first septet of text.
+	Data []byte
+
+	// Text is the user data decoded per Alphabet. It is empty for 8
+	// bit data and for compressed user data, which is not supported.
+	Text string
+}
+
+// UnmarshalDeliver decodes an SMS-DELIVER PDU as a modem reports it in
+// PDU mode, starting with the SMSC address.
+func UnmarshalDeliver(pdu []byte) (*SMSDeliver, error) {
+	var d SMSDeliver
+
+	smsc, n, err := decodeSMSCAddress(pdu)
+	if err != nil {
+		return nil, err
+	}
+	d.SMSC = smsc
+	b := pdu[n:]
+
+	if len(b) < 1 {
+		return nil, fmt.Errorf("%w: first octet", ErrTruncated)
+	}
+	first := b[0]
+	if mti := first & 0x03; mti != mtiDeliver {
+		return nil, fmt.Errorf("not an SMS-DELIVER, TP-MTI %d", mti)
+	}
+	d.MoreMessages = first&0x04 == 0
+	d.StatusReport = first&0x20 != 0
+	d.ReplyPath = first&0x80 != 0
+	udhi := first&0x40 != 0
+	b = b[1:]
+
+	d.Originator, n, err = decodeAddress(b)
+	if err != nil {
+		return nil, fmt.Errorf("originating address: %w", err)
+	}
+	b = b[n:]
+
+	// TP-PID, TP-DCS, TP-SCTS and TP-UDL
+	if len(b) < 1+1+7+1 {
+		return nil, fmt.Errorf("%w: tpdu fields", ErrTruncated)
+	}
+	d.PID = b[0]
+	d.DCS = b[1]
+	d.Timestamp, err = decodeTimestamp(b[2:9])
+	if err != nil {
+		return nil, err
+	}
+	udl := int(b[9])
+	ud := b[10:]
+
+	alphabet, compressed := dcsAlphabet(d.DCS)
+	d.Alphabet = alphabet
+
+	if err := d.decodeUserData(ud, udl, udhi, compressed); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// decodeUserData decodes TP-UD, whose length udl counts septets for
+// uncompressed 7 bit data and octets otherwise.
+func (d *SMSDeliver) decodeUserData(ud []byte, udl int, udhi, compressed bool) error {
+	septets := d.Alphabet == Alphabet7Bit && !compressed
+
+	octets := udl
+	if septets {
+		octets = (udl*7 + 7) / 8
+	}
+	if len(ud) < octets {
+		return fmt.Errorf("%w: user data of %d octets, have %d", ErrTruncated, octets, len(ud))
+	}
+	ud = ud[:octets]
+
+	headerOctets := 0
+	if udhi {
+		udh, payload, err := ParseUDH(ud)
+		if err != nil {
+			return err
+		}
+		d.UDH = udh
+		headerOctets = len(ud) - len(payload)
+	}
+
+	if !septets {
+		d.Data = ud[headerOctets:]
+		if !compressed && d.Alphabet == AlphabetUCS2 {
+			d.Text = DecodeUCS2(d.Data)
+		}
+		return nil
+	}
+
+	// The text starts at the septet boundary after the header, with
+	// fill bits in between.
+	headerSeptets := (headerOctets*8 + 6) / 7
+	textSeptets := udl - headerSeptets
+	if textSeptets < 0 {
+		return fmt.Errorf("user data header of %d septets exceeds user data length %d", headerSeptets, udl)
+	}
+	d.Data = shiftBits(ud, headerSeptets*7)
+	d.Text = gsm7.Unpack(d.Data, textSeptets)
+	return nil
+}
+
+// shiftBits returns the bits of b from bit offset on, realigned to
+// start at bit 0 of a new slice.
+func shiftBits(b []byte, offset int) []byte {
+	idx, shift := offset/8, uint(offset%8)
+	if idx >= len(b) {
+		return []byte{}
+	}
+	src := b[idx:]
+	if shift == 0 {
+		return append([]byte{}, src...)
+	}
+	out := make([]byte, len(src))
+	for i := range src {
+		out[i] = src[i] >> shift
+		if i+1 < len(src) {
+			out[i] |= src[i+1] << (8 - shift)
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package sms
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshalDeliver(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		pdu  string
+		want SMSDeliver
+	}{
+		{
+			// the example SMS-DELIVER widely used to document PDU mode
+			name: "7bit",
+			pdu:  "07917283010010F5040BC87238880900F10000993092516195800AE8329BFD4697D9EC37",
+			want: SMSDeliver{
+				SMSC:         Address{Number: "+27381000015", Type: 0x91},
+				Originator:   Address{Number: "27838890001", Type: 0xc8},
+				MoreMessages: false,
+				Alphabet:     Alphabet7Bit,
+				Timestamp:    time.Date(2099, 3, 29, 15, 16, 59, 0, time.FixedZone("", 2*60*60)),
+				Data:         mustHex(t, "E8329BFD4697D9EC37"),
+				Text:         "hellohello",
+			},
+		},
+		{
+			name: "ucs2",
+			pdu:  "07911326040000F0040B911346610089F60008204090517445800C4F60597D4E16754C00210021",
+			want: SMSDeliver{
+				SMSC:       Address{Number: "+31624000000", Type: 0x91},
+				Originator: Address{Number: "+31641600986", Type: 0x91},
+				DCS:        0x08,
+				Alphabet:   AlphabetUCS2,
+				Timestamp:  time.Date(2002, 4, 9, 15, 47, 54, 0, time.FixedZone("", 2*60*60)),
+				Data:       mustHex(t, "4F60597D4E16754C00210021"),
+				Text:       "你好世界!!",
+			},
+		},
+		{
+			name: "ucs2 emoji",
+			pdu:  "00040B911346610089F600082040905174458012D83DDC4B00200048006500790020D83CDF89",
+			want: SMSDeliver{
+				Originator: Address{Number: "+31641600986", Type: 0x91},
+				DCS:        0x08,
+				Alphabet:   AlphabetUCS2,
+				Timestamp:  time.Date(2002, 4, 9, 15, 47, 54, 0, time.FixedZone("", 2*60*60)),
+				Data:       mustHex(t, "D83DDC4B00200048006500790020D83CDF89"),
+				Text:       "👋 Hey 🎉",
+			},
+		},
+		{
+			// first segment of a concatenated message, the text
+			// starting after a fill bit
+			name: "7bit with udh",
+			pdu:  "00440B919171563412F000004210132143650A09050003120201D069",
+			want: SMSDeliver{
+				Originator: Address{Number: "+19176543210", Type: 0x91},
+				Alphabet:   Alphabet7Bit,
+				Timestamp:  time.Date(2024, 1, 31, 12, 34, 56, 0, time.FixedZone("", -5*60*60)),
+				UDH: &UDH{
+					Elements: []InformationElement{{ID: IEConcat8, Data: []byte{0x12, 0x02, 0x01}}},
+					Concat:   &Concat{Reference: 0x12, Total: 2, Sequence: 1},
+				},
+				Data: []byte{0xe8, 0x34},
+				Text: "hi",
+			},
+		},
+		{
+			name: "8bit alphanumeric sender",
+			pdu:  "00000CD0C7F7FBCC2E03000442900141000000040102FEFF",
+			want: SMSDeliver{
+				Originator:   Address{Number: "Google", Type: 0xd0},
+				MoreMessages: true,
+				DCS:          0x04,
+				Alphabet:     Alphabet8Bit,
+				Timestamp:    time.Date(2024, 9, 10, 14, 0, 0, 0, time.FixedZone("", 0)),
+				Data:         []byte{0x01, 0x02, 0xfe, 0xff},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := UnmarshalDeliver(mustHex(t, tc.pdu))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opt := cmp.Comparer(func(a, b time.Time) bool {
+				_, ao := a.Zone()
+				_, bo := b.Zone()
+				return a.Equal(b) && ao == bo
+			})
+			if diff := cmp.Diff(&tc.want, got, opt); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDeliverErrors(t *testing.T) {
+	pdu := mustHex(t, "07917283010010F5040BC87238880900F10000993092516195800AE8329BFD4697D9EC37")
+	for i := 0; i < len(pdu); i++ {
+		if _, err := UnmarshalDeliver(pdu[:i]); !errors.Is(err, ErrTruncated) {
+			t.Errorf("truncated to %d: got %v", i, err)
+		}
+	}
+
+	submit := append([]byte{}, pdu...)
+	submit[8] = 0x01
+	if _, err := UnmarshalDeliver(submit); err == nil {
+		t.Error("expected error for an SMS-SUBMIT")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,257 @@
+// Package sms encodes and decodes SMS transfer layer PDUs as defined
+// by 3GPP TS 23.040 (formerly GSM 03.40), in the form modems exchange
+// them in PDU mode: an SMSC address followed by the TPDU.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/psanford/gsm/gsm7"
+)
+
+// ErrTruncated is returned for a PDU that ends before its last field.
+var ErrTruncated = errors.New("truncated sms pdu")
+
+// Alphabet is the character set of the user data, as given by the
+// data coding scheme.
+type Alphabet int
+
+const (
+	Alphabet7Bit Alphabet = iota
+	Alphabet8Bit
+	AlphabetUCS2
+)
+
+func (a Alphabet) String() string {
+	switch a {
+	case Alphabet7Bit:
+		return "7bit"
+	case Alphabet8Bit:
+		return "8bit"
+	case AlphabetUCS2:
+		return "ucs2"
+	}
+	return fmt.Sprintf("Alphabet<%d>", int(a))
+}
+
+// dcsAlphabet returns the alphabet of a TP-DCS value, and whether the
+// user data is compressed, following TS 23.038 section 4. Reserved
+// coding groups and alphabets are taken as the 7 bit default alphabet.
+func dcsAlphabet(dcs byte) (Alphabet, bool) {
+	switch dcs >> 4 {
+	case 0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7:
+		// General data coding and automatic deletion groups
+		compressed := dcs&0x20 != 0
+		switch (dcs >> 2) & 0x03 {
+		case 1:
+			return Alphabet8Bit, compressed
+		case 2:
+			return AlphabetUCS2, compressed
+		}
+		return Alphabet7Bit, compressed
+	case 0xe:
+		// Message waiting indication, store message, UCS2
+		return AlphabetUCS2, false
+	case 0xf:
+		// Data coding/message class
+		if dcs&0x04 != 0 {
+			return Alphabet8Bit, false
+		}
+	}
+	return Alphabet7Bit, false
+}
+
+// Address is an SMSC or TP address.
+type Address struct {
+	// Number is the address digits, with a leading "+" for an
+	// international number, or the text of an alphanumeric address.
+	Number string
+
+	// Type is the Type-of-Address octet: the type of number in bits
+	// 6-4 and the numbering plan in bits 3-0.
+	Type byte
+}
+
+// Type of number values, from bits 6-4 of the Type-of-Address.
+const (
+	TypeInternational = 0x1
+	TypeAlphanumeric  = 0x5
+)
+
+// TypeOfNumber returns the type of number field of the address type.
+func (a Address) TypeOfNumber() int {
+	return int(a.Type>>4) & 0x07
+}
+
+// semiOctetDigits are the characters of the BCD semi-octets, with 0xf
+// as the filler of an odd length number.
+const semiOctetDigits = "0123456789*#abc"
+
+// decodeSemiOctets decodes n BCD digits, low nibble first.
+func decodeSemiOctets(b []byte, n int) string {
+	var s strings.Builder
+	for i := 0; i < n; i++ {
+		d := b[i/2]
+		if i%2 == 0 {
+			d &= 0x0f
+		} else {
+			d >>= 4
+		}
+		if int(d) >= len(semiOctetDigits) {
+			continue
+		}
+		s.WriteByte(semiOctetDigits[d])
+	}
+	return s.String()
+}
+
+// encodeSemiOctets appends the BCD semi-octets of digits, low nibble
+// first, padding an odd count with 0xf.
+func encodeSemiOctets(b []byte, digits string) ([]byte, error) {
+	for i := 0; i < len(digits); i += 2 {
+		lo := strings.IndexByte(semiOctetDigits, digits[i])
+		hi := 0xf
+		if i+1 < len(digits) {
+			hi = strings.IndexByte(semiOctetDigits, digits[i+1])
+		}
+		if lo < 0 || hi < 0 {
+			return nil, fmt.Errorf("invalid address digits %q", digits)
+		}
+		b = append(b, byte(hi<<4|lo))
+	}
+	return b, nil
+}
+
+// splitNumber returns the Type-of-Address and digits of a phone
+// number, which is international if it starts with "+".
+func splitNumber(number string) (byte, string, error) {
+	toa, digits := byte(0x81), number
+	if strings.HasPrefix(number, "+") {
+		toa, digits = 0x91, number[1:]
+	}
+	if digits == "" {
+		return 0, "", fmt.Errorf("invalid address %q", number)
+	}
+	return toa, digits, nil
+}
+
+// appendAddress appends a TP address, the inverse of decodeAddress.
+// Only numeric addresses are supported.
+func appendAddress(b []byte, number string) ([]byte, error) {
+	toa, digits, err := splitNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, byte(len(digits)), toa)
+	return encodeSemiOctets(b, digits)
+}
+
+// appendSMSCAddress appends the SMSC address that precedes the TPDU,
+// the inverse of decodeSMSCAddress. An empty number is written as a
+// zero length, leaving the modem to use its configured SMSC.
+func appendSMSCAddress(b []byte, number string) ([]byte, error) {
+	if number == "" {
+		return append(b, 0), nil
+	}
+	toa, digits, err := splitNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, byte(1+(len(digits)+1)/2), toa)
+	return encodeSemiOctets(b, digits)
+}
+
+// decodeAddress decodes a TP address, whose length octet counts the
+// semi-octets of the address value. It returns the address and the
+// number of octets read.
+func decodeAddress(b []byte) (Address, int, error) {
+	if len(b) < 2 {
+		return Address{}, 0, fmt.Errorf("%w: address", ErrTruncated)
+	}
+	digits := int(b[0])
+	n := 2 + (digits+1)/2
+	if len(b) < n {
+		return Address{}, 0, fmt.Errorf("%w: address", ErrTruncated)
+	}
+
+	addr := Address{Type: b[1]}
+	value := b[2:n]
+	switch addr.TypeOfNumber() {
+	case TypeAlphanumeric:
+		addr.Number = gsm7.Unpack(value, digits*4/7)
+	case TypeInternational:
+		addr.Number = "+" + decodeSemiOctets(value, digits)
+	default:
+		addr.Number = decodeSemiOctets(value, digits)
+	}
+	return addr, n, nil
+}
+
+// decodeSMSCAddress decodes the SMSC address that precedes the TPDU,
+// whose length octet counts the octets of the type and value. It
+// returns the address and the number of octets read.
+func decodeSMSCAddress(b []byte) (Address, int, error) {
+	if len(b) < 1 {
+		return Address{}, 0, fmt.Errorf("%w: smsc address", ErrTruncated)
+	}
+	l := int(b[0])
+	if l == 0 {
+		return Address{}, 1, nil
+	}
+	if len(b) < 1+l {
+		return Address{}, 0, fmt.Errorf("%w: smsc address", ErrTruncated)
+	}
+
+	addr := Address{Type: b[1]}
+	digits := decodeSemiOctets(b[2:1+l], 2*(l-1))
+	if addr.TypeOfNumber() == TypeInternational {
+		digits = "+" + digits
+	}
+	addr.Number = digits
+	return addr, 1 + l, nil
+}
+
+// decodeTimestamp decodes a 7 octet TP-SCTS: year, month, day, hour,
+// minute and second as swapped BCD semi-octets, then the offset from
+// UTC in quarter hours with its sign in bit 3.
+func decodeTimestamp(b []byte) (time.Time, error) {
+	var v [6]int
+	for i := range v {
+		lo, hi := int(b[i]&0x0f), int(b[i]>>4)
+		if lo > 9 || hi > 9 {
+			return time.Time{}, fmt.Errorf("invalid timestamp digits %02x", b[i])
+		}
+		v[i] = lo*10 + hi
+	}
+
+	tz := b[6]
+	quarters := int(tz&0x07)*10 + int(tz>>4)
+	offset := quarters * 15 * 60
+	if tz&0x08 != 0 {
+		offset = -offset
+	}
+
+	return time.Date(2000+v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], 0, time.FixedZone("", offset)), nil
+}
+
+// DecodeUCS2 decodes UTF-16BE text, the UCS2 alphabet of SMS, which
+// in practice carries surrogate pairs for characters outside the
+// Basic Multilingual Plane such as emoji. Unpaired surrogates, and the
+// final octet of odd length data, decode as U+FFFD rather than being
+// dropped.
+func DecodeUCS2(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	s := string(utf16.Decode(units))
+	if len(data)%2 != 0 {
+		s += string(unicode.ReplacementChar)
+	}
+	return s
+}
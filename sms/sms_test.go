@@ -0,0 +1,25 @@
+package sms
+
+import "testing"
+
+func TestDecodeUCS2(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want string
+	}{
+		{"", ""},
+		{"\x4f\x60\x59\x7d", "你好"},
+		{"\x00H\x00i\x00 \xd8\x3d\xde\x00", "Hi 😀"},
+		// odd length: the stray octet isn't dropped silently
+		{"\x00H\x00", "H�"},
+		// unpaired high and low surrogates
+		{"\xd8\x3d\x00!", "�!"},
+		{"\xde\x00\x00!", "�!"},
+		// a pair cut off by the end of the data
+		{"\x00!\xd8\x3d", "!�"},
+	} {
+		if got := DecodeUCS2([]byte(tc.data)); got != tc.want {
+			t.Errorf("DecodeUCS2(%x) = %q want %q", tc.data, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package sms
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf16"
+
+	"github.com/psanford/gsm/gsm7"
+)
+
+const (
+	// mtiSubmit is the TP-MTI of an SMS-SUBMIT.
+	mtiSubmit = 0x01
+
+	// vpfRelative is the TP-VPF for a relative validity period.
+	vpfRelative = 0x10
+
+	// User data capacity of a single message, and of each segment of
+	// a concatenated one once its 6 octet User-Data-Header is taken.
+	maxSeptets         = 160
+	maxConcatSeptets   = 153
+	maxUCS2Units       = 70
+	maxConcatUCS2Units = 67
+
+	concatUDHLen = 5
+)
+
+// SubmitOptions configures MarshalSubmit.
+type SubmitOptions struct {
+	// SMSC is the service centre number. If empty the modem's
+	// configured SMSC is used.
+	SMSC string
+
+	// ValidityPeriod is how long the SMSC should keep trying to
+	// deliver the message. It is rounded up to a period TP-VP can
+	// express, at most 63 weeks. Zero leaves it to the SMSC.
+	ValidityPeriod time.Duration
+
+	// StatusReport requests a status report, TP-SRR.
+	StatusReport bool
+
+	// MessageReference is the TP-MR. Modems usually replace it.
+	MessageReference byte
+
+	// ConcatReference is the reference number shared by the segments
+	// of a concatenated message. If zero NewConcatReference is used.
+	ConcatReference byte
+}
+
+// MarshalSubmit encodes text to dest as SMS-SUBMIT PDUs, each starting
+// with the SMSC address as AT+CMGS expects in PDU mode. The text is
+// sent in the GSM 7 bit default alphabet if it can be, and as UCS2
+// otherwise. Text too long for one message is split into segments with
+// an 8-bit concatenation information element, one PDU per segment,
+// without splitting an escape sequence or surrogate pair.
+//
+// dest must be a phone number, with a leading "+" if international.
+func MarshalSubmit(dest string, text string, opts SubmitOptions) ([][]byte, error) {
+	var (
+		segments [][]byte
+		septets  []int
+		dcs      byte
+	)
+	if gsm7.Encodable(text) {
+		segments, septets = splitGSM7(text)
+	} else {
+		dcs = 0x08
+		segments = splitUCS2(text)
+	}
+	if len(segments) > 255 {
+		return nil, fmt.Errorf("text needs %d segments, more than the maximum of 255", len(segments))
+	}
+
+	ref := opts.ConcatReference
+	if ref == 0 {
+		ref = NewConcatReference()
+	}
+
+	pdus := make([][]byte, 0, len(segments))
+	for i, seg := range segments {
+		var udh []byte
+		if len(segments) > 1 {
+			udh = []byte{concatUDHLen, IEConcat8, 3, ref, byte(len(segments)), byte(i + 1)}
+		}
+
+		var ud []byte
+		var udl int
+		if dcs == 0 {
+			ud, udl = appendSeptets(udh, seg, septets[i])
+		} else {
+			ud, udl = append(udh, seg...), len(udh)+len(seg)
+		}
+
+		pdu, err := marshalSubmitPDU(dest, dcs, udh != nil, ud, udl, opts)
+		if err != nil {
+			return nil, err
+		}
+		pdus = append(pdus, pdu)
+	}
+	return pdus, nil
+}
+
+// marshalSubmitPDU encodes one SMS-SUBMIT carrying user data ud of
+// length udl.
+func marshalSubmitPDU(dest string, dcs byte, udhi bool, ud []byte, udl int, opts SubmitOptions) ([]byte, error) {
+	b, err := appendSMSCAddress(nil, opts.SMSC)
+	if err != nil {
+		return nil, fmt.Errorf("smsc address: %w", err)
+	}
+
+	first := byte(mtiSubmit)
+	if opts.ValidityPeriod > 0 {
+		first |= vpfRelative
+	}
+	if opts.StatusReport {
+		first |= 0x20
+	}
+	if udhi {
+		first |= 0x40
+	}
+	b = append(b, first, opts.MessageReference)
+
+	b, err = appendAddress(b, dest)
+	if err != nil {
+		return nil, fmt.Errorf("destination address: %w", err)
+	}
+
+	b = append(b, 0x00, dcs) // TP-PID, TP-DCS
+	if opts.ValidityPeriod > 0 {
+		b = append(b, relativeValidity(opts.ValidityPeriod))
+	}
+	b = append(b, byte(udl))
+	return append(b, ud...), nil
+}
+
+// relativeValidity returns the relative TP-VP for the shortest period
+// of at least d.
+//
+//	0-143    (VP+1) * 5 minutes
+//	144-167  12 hours + (VP-143) * 30 minutes
+//	168-196  (VP-166) days
+//	197-255  (VP-192) weeks
+func relativeValidity(d time.Duration) byte {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	switch {
+	case minutes <= 12*60:
+		v := (minutes+4)/5 - 1
+		if v < 0 {
+			v = 0
+		}
+		return byte(v)
+	case minutes <= 24*60:
+		return byte(143 + (minutes-12*60+29)/30)
+	case minutes <= 30*24*60:
+		return byte(166 + (minutes+24*60-1)/(24*60))
+	}
+	weeks := (minutes + 7*24*60 - 1) / (7 * 24 * 60)
+	if weeks > 63 {
+		weeks = 63
+	}
+	return byte(192 + weeks)
+}
+
+// splitGSM7 splits text into the packed septets of each segment and
+// their septet counts.
+func splitGSM7(text string) ([][]byte, []int) {
+	total := 0
+	for _, r := range text {
+		total += gsm7.SeptetLen(r)
+	}
+	if total <= maxSeptets {
+		packed, n := gsm7.Pack(text)
+		return [][]byte{packed}, []int{n}
+	}
+
+	var (
+		segments [][]byte
+		counts   []int
+		start, n int
+	)
+	for i, r := range text {
+		l := gsm7.SeptetLen(r)
+		if n+l > maxConcatSeptets {
+			packed, c := gsm7.Pack(text[start:i])
+			segments, counts = append(segments, packed), append(counts, c)
+			start, n = i, 0
+		}
+		n += l
+	}
+	packed, c := gsm7.Pack(text[start:])
+	return append(segments, packed), append(counts, c)
+}
+
+// splitUCS2 splits text into the UTF-16BE user data of each segment.
+func splitUCS2(text string) [][]byte {
+	units := utf16.Encode([]rune(text))
+	if len(units) <= maxUCS2Units {
+		return [][]byte{encodeUCS2(units)}
+	}
+
+	var segments [][]byte
+	for len(units) > maxConcatUCS2Units {
+		n := maxConcatUCS2Units
+		if u := units[n-1]; u >= 0xd800 && u < 0xdc00 {
+			// don't separate a high surrogate from its low surrogate
+			n--
+		}
+		segments = append(segments, encodeUCS2(units[:n]))
+		units = units[n:]
+	}
+	return append(segments, encodeUCS2(units))
+}
+
+func encodeUCS2(units []uint16) []byte {
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return b
+}
+
+// appendSeptets appends packed septets to a User-Data-Header, starting
+// at the first septet boundary after it, and returns the user data and
+// its length in septets.
+func appendSeptets(udh []byte, packed []byte, septets int) ([]byte, int) {
+	headerSeptets := (len(udh)*8 + 6) / 7
+	udl := headerSeptets + septets
+
+	ud := make([]byte, (udl*7+7)/8)
+	copy(ud, udh)
+	offset := headerSeptets * 7
+	idx, shift := offset/8, uint(offset%8)
+	for i, c := range packed {
+		if idx+i < len(ud) {
+			ud[idx+i] |= c << shift
+		}
+		if shift > 0 && idx+i+1 < len(ud) {
+			ud[idx+i+1] |= c >> (8 - shift)
+		}
+	}
+	return ud, udl
+}
@@ -0,0 +1,135 @@
+package sms
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psanford/gsm/gsm7"
+)
+
+func TestMarshalSubmit(t *testing.T) {
+	pdus, err := MarshalSubmit("+15551234567", "hello", SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0001000b915155214365f7000005e8329bfd06"
+	if len(pdus) != 1 || hex.EncodeToString(pdus[0]) != want {
+		t.Fatalf("got %x want %s", pdus, want)
+	}
+
+	pdus, err = MarshalSubmit("5551234", "hi", SubmitOptions{
+		SMSC:             "+27381000015",
+		ValidityPeriod:   24 * time.Hour,
+		StatusReport:     true,
+		MessageReference: 7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "07917283010010f5" + // SMSC
+		"31" + "07" + // SUBMIT, relative VP, status report; TP-MR
+		"0781551532f4" + // 5551234, unknown type of number
+		"0000" + "a7" + // PID, DCS, VP 24 hours
+		"02e834"
+	if len(pdus) != 1 || hex.EncodeToString(pdus[0]) != want {
+		t.Fatalf("got %x want %s", pdus, want)
+	}
+
+	if _, err := MarshalSubmit("not a number", "hi", SubmitOptions{}); err == nil {
+		t.Error("expected error for invalid destination")
+	}
+}
+
+func TestMarshalSubmitConcatenated(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     string
+		segments int
+	}{
+		{"gsm7", strings.Repeat("0123456789", 20) + "{}", 2},
+		// a surrogate pair that would straddle the first segment
+		{"ucs2", strings.Repeat("世", 66) + "😀" + strings.Repeat("界", 40), 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pdus, err := MarshalSubmit("+15551234567", tc.text, SubmitOptions{ConcatReference: 0x42})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pdus) != tc.segments {
+				t.Fatalf("got %d segments", len(pdus))
+			}
+
+			var text string
+			for i, pdu := range pdus {
+				// SMSC, first octet, MR, 11 digit address, PID
+				b := pdu[1+1+1+8+1:]
+				dcs, udl, ud := b[0], int(b[1]), b[2:]
+				if pdu[1]&0x40 == 0 {
+					t.Fatalf("segment %d has no udh", i)
+				}
+				udh := []byte{5, 0, 3, 0x42, byte(len(pdus)), byte(i + 1)}
+				if got := ud[:6]; string(got) != string(udh) {
+					t.Fatalf("segment %d udh %x want %x", i, got, udh)
+				}
+				switch dcs {
+				case 0x00:
+					if udl > maxSeptets {
+						t.Errorf("segment %d has %d septets", i, udl)
+					}
+					text += gsm7.Unpack(shiftBits(ud, 7*7), udl-7)
+				case 0x08:
+					if udl > 140 || udl != len(ud) {
+						t.Errorf("segment %d has udl %d for %d octets", i, udl, len(ud))
+					}
+					text += DecodeUCS2(ud[6:])
+				default:
+					t.Fatalf("unexpected dcs %x", dcs)
+				}
+			}
+			if text != tc.text {
+				t.Errorf("reassembled %q want %q", text, tc.text)
+			}
+		})
+	}
+}
+
+func TestMarshalSubmitConcatReference(t *testing.T) {
+	text := strings.Repeat("0123456789", 20)
+	ref := func() byte {
+		pdus, err := MarshalSubmit("+15551234567", text, SubmitOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// SMSC, first octet, MR, 11 digit address, PID, DCS, UDL,
+		// UDHL, IEI, IEDL
+		return pdus[0][1+1+1+8+1+1+1+3]
+	}
+
+	if first, second := ref(), ref(); first == second {
+		t.Errorf("the same text sent twice reused reference %d", first)
+	}
+}
+
+func TestRelativeValidity(t *testing.T) {
+	for _, tc := range []struct {
+		d    time.Duration
+		want byte
+	}{
+		{time.Minute, 0},
+		{5 * time.Minute, 0},
+		{6 * time.Minute, 1},
+		{12 * time.Hour, 143},
+		{12*time.Hour + time.Minute, 144},
+		{24 * time.Hour, 167},
+		{2 * 24 * time.Hour, 168},
+		{30 * 24 * time.Hour, 196},
+		{31 * 24 * time.Hour, 197},
+		{1000 * 24 * time.Hour, 255},
+	} {
+		if got := relativeValidity(tc.d); got != tc.want {
+			t.Errorf("%v: got %d want %d", tc.d, got, tc.want)
+		}
+	}
+}
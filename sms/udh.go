@@ -0,0 +1,121 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// Information element identifiers of TS 23.040 section 9.2.3.24.
+const (
+	IEConcat8  = 0x00
+	IEPort8    = 0x04
+	IEPort16   = 0x05
+	IEConcat16 = 0x08
+)
+
+// concatRef is the last reference number NewConcatReference returned.
+var concatRef = rand.Uint32()
+
+// NewConcatReference returns a reference number for the segments of a
+// new concatenated message. References come from a counter starting at
+// a random value, so messages sent in turn get different references
+// and their segments can't be mixed up by the recipient.
+func NewConcatReference() byte {
+	return byte(atomic.AddUint32(&concatRef, 1))
+}
+
+// ErrInvalidUDH is returned, wrapped, for a malformed
+// User-Data-Header.
+var ErrInvalidUDH = errors.New("invalid user data header")
+
+// InformationElement is one element of a User-Data-Header.
+type InformationElement struct {
+	ID   byte
+	Data []byte
+}
+
+// UDH is a parsed User-Data-Header.
+type UDH struct {
+	// Elements lists every information element in order.
+	Elements []InformationElement
+
+	// Concat is the concatenated message element, 8-bit or 16-bit
+	// reference, or nil if there is none.
+	Concat *Concat
+
+	// Ports is the application port addressing element, 8-bit or
+	// 16-bit ports, or nil if there is none.
+	Ports *Ports
+}
+
+// Concat identifies one segment of a concatenated message.
+type Concat struct {
+	// Reference is shared by the segments of one message.
+	Reference int
+	// Total is the number of segments.
+	Total int
+	// Sequence is the number of this segment, counting from 1.
+	Sequence int
+}
+
+// Ports addresses the user data to an application port.
+type Ports struct {
+	Destination int
+	Source      int
+}
+
+// ParseUDH parses the User-Data-Header at the start of data, including
+// its length octet, and returns it with the user data that follows.
+// The concatenation and port addressing elements are decoded into
+// Concat and Ports; malformed instances of them are an error, while
+// other elements are only listed. If an element appears more than once
+// the last one is used.
+func ParseUDH(data []byte) (*UDH, []byte, error) {
+	if len(data) < 1 || int(data[0])+1 > len(data) {
+		return nil, nil, fmt.Errorf("%w: truncated header", ErrInvalidUDH)
+	}
+	hdr, payload := data[1:1+int(data[0])], data[1+int(data[0]):]
+
+	var udh UDH
+	for len(hdr) > 0 {
+		if len(hdr) < 2 || int(hdr[1])+2 > len(hdr) {
+			return nil, nil, fmt.Errorf("%w: truncated information element", ErrInvalidUDH)
+		}
+		id, ie := hdr[0], hdr[2:2+int(hdr[1])]
+		hdr = hdr[2+int(hdr[1]):]
+
+		udh.Elements = append(udh.Elements, InformationElement{ID: id, Data: ie})
+
+		var want int
+		switch id {
+		case IEConcat8:
+			want = 3
+			if len(ie) == want {
+				udh.Concat = &Concat{Reference: int(ie[0]), Total: int(ie[1]), Sequence: int(ie[2])}
+			}
+		case IEConcat16:
+			want = 4
+			if len(ie) == want {
+				udh.Concat = &Concat{Reference: int(ie[0])<<8 | int(ie[1]), Total: int(ie[2]), Sequence: int(ie[3])}
+			}
+		case IEPort8:
+			want = 2
+			if len(ie) == want {
+				udh.Ports = &Ports{Destination: int(ie[0]), Source: int(ie[1])}
+			}
+		case IEPort16:
+			want = 4
+			if len(ie) == want {
+				udh.Ports = &Ports{Destination: int(ie[0])<<8 | int(ie[1]), Source: int(ie[2])<<8 | int(ie[3])}
+			}
+		default:
+			continue
+		}
+		if len(ie) != want {
+			return nil, nil, fmt.Errorf("%w: element 0x%02x has length %d, want %d", ErrInvalidUDH, id, len(ie), want)
+		}
+	}
+	return &udh, payload, nil
+}
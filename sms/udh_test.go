@@ -0,0 +1,82 @@
+package sms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseUDH(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    []byte
+		want    *UDH
+		payload []byte
+	}{
+		{
+			name: "8-bit concatenation",
+			data: []byte{0x05, 0x00, 0x03, 0x2a, 0x03, 0x02, 'h', 'i'},
+			want: &UDH{
+				Elements: []InformationElement{{ID: IEConcat8, Data: []byte{0x2a, 0x03, 0x02}}},
+				Concat:   &Concat{Reference: 0x2a, Total: 3, Sequence: 2},
+			},
+			payload: []byte("hi"),
+		},
+		{
+			name: "16-bit concatenation",
+			data: []byte{0x06, 0x08, 0x04, 0x12, 0x34, 0x02, 0x01, 'h', 'i'},
+			want: &UDH{
+				Elements: []InformationElement{{ID: IEConcat16, Data: []byte{0x12, 0x34, 0x02, 0x01}}},
+				Concat:   &Concat{Reference: 0x1234, Total: 2, Sequence: 1},
+			},
+			payload: []byte("hi"),
+		},
+		{
+			name: "wap push ports and an unknown element",
+			data: []byte{0x0a, 0x05, 0x04, 0x0b, 0x84, 0x23, 0xf0, 0x70, 0x02, 0xaa, 0xbb},
+			want: &UDH{
+				Elements: []InformationElement{
+					{ID: IEPort16, Data: []byte{0x0b, 0x84, 0x23, 0xf0}},
+					{ID: 0x70, Data: []byte{0xaa, 0xbb}},
+				},
+				Ports: &Ports{Destination: 2948, Source: 9200},
+			},
+			payload: []byte{},
+		},
+		{
+			name: "8-bit ports",
+			data: []byte{0x04, 0x04, 0x02, 0xf5, 0x00, 'x'},
+			want: &UDH{
+				Elements: []InformationElement{{ID: IEPort8, Data: []byte{0xf5, 0x00}}},
+				Ports:    &Ports{Destination: 0xf5, Source: 0},
+			},
+			payload: []byte("x"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			udh, payload, err := ParseUDH(tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, udh); diff != "" {
+				t.Errorf("udh mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.payload, payload); diff != "" {
+				t.Errorf("payload mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":             {},
+		"truncated header":  {0x05, 0x00, 0x03},
+		"truncated element": {0x02, 0x00, 0x03},
+		"short concat":      {0x04, 0x00, 0x02, 0x01, 0x01},
+		"short ports":       {0x04, 0x05, 0x02, 0x0b, 0x84},
+	} {
+		if _, _, err := ParseUDH(data); !errors.Is(err, ErrInvalidUDH) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
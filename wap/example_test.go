@@ -0,0 +1,45 @@
+package wap_test
+
+import (
+	"fmt"
+
+	"github.com/psanford/gsm/mms"
+	"github.com/psanford/gsm/wap"
+)
+
+func ExampleStripWSP() {
+	packet := []byte{
+		0x01,       // transaction id
+		0x06,       // push
+		0x03,       // headers length
+		0xbe,       // application/vnd.wap.mms-message
+		0xaf, 0x84, // X-Wap-Application-Id: x-wap-application:mms.ua
+		0x8c, 0x82, // X-Mms-Message-Type: m-notification-ind
+	}
+
+	ct, body, err := wap.StripWSP(packet)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%s % x\n", ct, body)
+	// Output: application/vnd.wap.mms-message 8c 82
+}
+
+func ExampleUnmarshalPushNotification() {
+	packet := []byte{
+		0x01, 0x06, 0x03, 0xbe, 0xaf, 0x84,
+		0x8c, 0x82, // X-Mms-Message-Type: m-notification-ind
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+		0x83, 'h', 't', 't', 'p', ':', '/', '/', 'x', 0x00, // X-Mms-Content-Location
+	}
+
+	msg, err := wap.UnmarshalPushNotification(packet)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(msg.Header[mms.MessageType][0])
+	fmt.Println(msg.Header[mms.ContentLocation][0])
+	// Output:
+	// m-notification-ind
+	// http://x
+}
@@ -0,0 +1,322 @@
+package wap
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/psanford/gsm/mms"
+)
+
+// PushNotification is a decoded WAP push.
+type PushNotification struct {
+	// Headers holds the WSP headers of the push keyed by field name,
+	// starting with its Content-Type. Well-known application ids in
+	// X-Wap-Application-Id are given as their URN, such as
+	// "x-wap-application:mms.ua". Integer values are in decimal and
+	// other length-prefixed values are in hex.
+	Headers map[string]string
+
+	// Type is the WSP PDU type the push arrived in, or 0 for a bare
+	// MMS PDU with no WSP layer.
+	Type WSPType
+
+	// TransactionID is the WSP transaction id of the push.
+	TransactionID byte
+
+	// Message is the decoded MMS PDU, or nil if the push's content
+	// type is not application/vnd.wap.mms-message.
+	Message *mms.Message
+
+	// ServiceIndication is the decoded Service Indication, or nil if
+	// the push is not one.
+	ServiceIndication *SI
+
+	// ServiceLoading is the decoded Service Loading document, or nil
+	// if the push is not one.
+	ServiceLoading *SL
+}
+
+// ContentType returns the content type of the push.
+func (p *PushNotification) ContentType() string {
+	return p.Headers["Content-Type"]
+}
+
+// ApplicationID returns the X-Wap-Application-Id of the push, or "" if
+// absent.
+func (p *PushNotification) ApplicationID() string {
+	return p.Headers["X-Wap-Application-Id"]
+}
+
+// UnmarshalPush decodes a WAP push, keeping its WSP headers. The body
+// is decoded according to the push's content type: an MMS PDU into
+// Message, a Service Indication into ServiceIndication or a Service
+// Loading document into ServiceLoading. Pushes of other types are
+// returned with just their headers.
+func UnmarshalPush(packet []byte) (*PushNotification, error) {
+	headers, body, err := splitWSP(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	p := PushNotification{
+		Headers: map[string]string{"Content-Type": mmsContentType},
+	}
+	if headers != nil {
+		p.TransactionID, p.Type = packet[0], WSPType(packet[1])
+		p.Headers, err = decodeHeaders(headers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch p.ContentType() {
+	case mmsContentType:
+		p.Message, err = mms.Unmarshal(body)
+	case siContentType:
+		p.ServiceIndication, err = decodeSI(body)
+	case siTextContentType:
+		p.ServiceIndication, err = decodeSIText(body)
+	case slContentType:
+		p.ServiceLoading, err = decodeSL(body)
+	case slTextContentType:
+		p.ServiceLoading, err = decodeSLText(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// decodeHeaders decodes a WSP header block: the Content-Type value
+// followed by the remaining headers.
+//
+//	Headers = Content-type-value *Header
+//	Header = Well-known-header | Application-header
+//	Well-known-header = Well-known-field-name Wap-value
+//	Application-header = Token-text Application-specific-value
+func decodeHeaders(b []byte) (map[string]string, error) {
+	n, err := valueLen(b)
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := decodeContentType(b[:n])
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{"Content-Type": contentType}
+
+	for b = b[n:]; len(b) > 0; b = b[n:] {
+		if b[0] < 128 {
+			name, rest, err := textString(b)
+			if err != nil {
+				return nil, err
+			}
+			value, rest2, err := textString(rest)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = value
+			n = len(b) - len(rest2)
+			continue
+		}
+
+		field := b[0] & 0x7f
+		n, err = valueLen(b[1:])
+		if err != nil {
+			return nil, err
+		}
+		value := b[1 : 1+n]
+		n++
+
+		name, ok := headerNames[field]
+		if !ok {
+			name = fmt.Sprintf("Unknown-Header-0x%02x", field)
+		}
+		if field == xWapApplicationID {
+			out[name] = applicationID(value)
+		} else {
+			out[name] = headerValue(value)
+		}
+	}
+
+	return out, nil
+}
+
+// valueLen returns the length of the encoded value at the start of b.
+//
+//	Wap-value = Short-integer | Value-length Data | Text-string
+func valueLen(b []byte) (int, error) {
+	if len(b) < 1 {
+		return 0, ErrInvalidPacket
+	}
+
+	first := b[0]
+	switch {
+	case first >= 128:
+		return 1, nil
+	case first < 31:
+		if 1+int(first) > len(b) {
+			return 0, ErrInvalidPacket
+		}
+		return 1 + int(first), nil
+	case first == 31:
+		l, n, err := decodeUintvar(b[1:])
+		if err != nil || uint64(1+n)+uint64(l) > uint64(len(b)) {
+			return 0, ErrInvalidPacket
+		}
+		return 1 + n + int(l), nil
+	default:
+		end := bytes.IndexByte(b, 0)
+		if end < 0 {
+			return 0, ErrInvalidPacket
+		}
+		return end + 1, nil
+	}
+}
+
+// textString splits the Text-string at the start of b from the rest.
+func textString(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", nil, ErrInvalidPacket
+	}
+	s := b[:end]
+	if len(s) > 0 && s[0] == 127 {
+		s = s[1:]
+	}
+	return string(s), b[end+1:], nil
+}
+
+// headerValue formats an encoded header value.
+func headerValue(v []byte) string {
+	switch {
+	case v[0] >= 128:
+		return strconv.Itoa(int(v[0] & 0x7f))
+	case v[0] < 31 && int(v[0]) == len(v)-1 && len(v) <= 9:
+		// Long-integer = Short-length Multi-octet-integer
+		var u uint64
+		for _, b := range v[1:] {
+			u = u<<8 | uint64(b)
+		}
+		return strconv.FormatUint(u, 10)
+	case v[0] <= 31:
+		return fmt.Sprintf("%x", v)
+	}
+	s, _, _ := textString(v)
+	return s
+}
+
+// applicationID formats an X-Wap-Application-Id value.
+//
+//	Application-id-value = Uri-value | App-assigned-code
+//	App-assigned-code = Integer-value
+func applicationID(v []byte) string {
+	s := headerValue(v)
+	if v[0] > 31 && v[0] < 128 {
+		return s
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	if urn, ok := applicationIDs[code]; ok {
+		return urn
+	}
+	return s
+}
+
+const xWapApplicationID = 0x2f
+
+// headerNames holds the WSP well-known field names, from WAP-230
+// table 39.
+var headerNames = map[byte]string{
+	0x00: "Accept",
+	0x01: "Accept-Charset",
+	0x02: "Accept-Encoding",
+	0x03: "Accept-Language",
+	0x04: "Accept-Ranges",
+	0x05: "Age",
+	0x06: "Allow",
+	0x07: "Authorization",
+	0x08: "Cache-Control",
+	0x09: "Connection",
+	0x0a: "Content-Base",
+	0x0b: "Content-Encoding",
+	0x0c: "Content-Language",
+	0x0d: "Content-Length",
+	0x0e: "Content-Location",
+	0x0f: "Content-MD5",
+	0x10: "Content-Range",
+	0x11: "Content-Type",
+	0x12: "Date",
+	0x13: "Etag",
+	0x14: "Expires",
+	0x15: "From",
+	0x16: "Host",
+	0x17: "If-Modified-Since",
+	0x18: "If-Match",
+	0x19: "If-None-Match",
+	0x1a: "If-Range",
+	0x1b: "If-Unmodified-Since",
+	0x1c: "Location",
+	0x1d: "Last-Modified",
+	0x1e: "Max-Forwards",
+	0x1f: "Pragma",
+	0x20: "Proxy-Authenticate",
+	0x21: "Proxy-Authorization",
+	0x22: "Public",
+	0x23: "Range",
+	0x24: "Referer",
+	0x25: "Retry-After",
+	0x26: "Server",
+	0x27: "Transfer-Encoding",
+	0x28: "Upgrade",
+	0x29: "User-Agent",
+	0x2a: "Vary",
+	0x2b: "Via",
+	0x2c: "Warning",
+	0x2d: "WWW-Authenticate",
+	0x2e: "Content-Disposition",
+	0x2f: "X-Wap-Application-Id",
+	0x30: "X-Wap-Content-URI",
+	0x31: "X-Wap-Initiator-URI",
+	0x32: "Accept-Application",
+	0x33: "Bearer-Indication",
+	0x34: "Push-Flag",
+	0x35: "Profile",
+	0x36: "Profile-Diff",
+	0x37: "Profile-Warning",
+	0x38: "Expect",
+	0x39: "TE",
+	0x3a: "Trailer",
+	0x3b: "Accept-Charset",
+	0x3c: "Accept-Encoding",
+	0x3d: "Cache-Control",
+	0x3e: "Content-Range",
+	0x3f: "X-Wap-Tod",
+	0x40: "Content-ID",
+	0x41: "Set-Cookie",
+	0x42: "Cookie",
+	0x43: "Encoding-Version",
+	0x44: "Profile-Warning",
+	0x45: "Content-Disposition",
+	0x46: "X-WAP-Security",
+	0x47: "Cache-Control",
+}
+
+// applicationIDs holds the registered push application ids from the
+// OMNA Push Application ID registry.
+var applicationIDs = map[int]string{
+	0x00: "x-wap-application:*",
+	0x01: "x-wap-application:push.sia",
+	0x02: "x-wap-application:wml.ua",
+	0x03: "x-wap-application:wta.ua",
+	0x04: "x-wap-application:mms.ua",
+	0x05: "x-wap-application:push.syncml",
+	0x06: "x-wap-application:loc.ua",
+	0x07: "x-wap-application:syncml.dm",
+	0x08: "x-wap-application:drm.ua",
+	0x09: "x-wap-application:emn.ua",
+	0x0a: "x-wap-application:wv.ua",
+}
@@ -0,0 +1,85 @@
+package wap
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/psanford/gsm/mms"
+)
+
+func TestUnmarshalPush(t *testing.T) {
+	body := []byte{
+		0x8c, 0x82, // X-Mms-Message-Type: m-notification-ind
+		0x98, 'a', 0x00, // X-Mms-Transaction-ID
+		0x8d, 0x92, // X-Mms-MMS-Version: 1.2
+	}
+
+	headers := []byte{
+		0xbe,       // application/vnd.wap.mms-message
+		0xaf, 0x84, // X-Wap-Application-Id: x-wap-application:mms.ua
+		0x8d, 0x02, 0x01, 0x00, // Content-Length: 256
+		0xb4, 0x81, // Push-Flag: 1
+	}
+	headers = append(headers, "X-Carrier\x00example\x00"...)
+
+	packet := append([]byte{0x01, 0x06, byte(len(headers))}, headers...)
+	packet = append(packet, body...)
+
+	p, err := UnmarshalPush(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Content-Type":         "application/vnd.wap.mms-message",
+		"X-Wap-Application-Id": "x-wap-application:mms.ua",
+		"Content-Length":       "256",
+		"Push-Flag":            "1",
+		"X-Carrier":            "example",
+	}
+	if diff := cmp.Diff(want, p.Headers); diff != "" {
+		t.Errorf("headers mismatch (-want +got):\n%s", diff)
+	}
+	if p.Type != WSPPush || p.TransactionID != 0x01 {
+		t.Errorf("got type %s, transaction id %d", p.Type, p.TransactionID)
+	}
+	if p.ApplicationID() != "x-wap-application:mms.ua" {
+		t.Errorf("application id got %q", p.ApplicationID())
+	}
+	if p.Message == nil || p.Message.MessageType() != mms.MNotificationInd {
+		t.Fatalf("got message %v", p.Message)
+	}
+
+	// A Service Indication push has no MMS PDU.
+	si := []byte{
+		0x01, 0x06, 0x03, 0xae, 0xaf, 0x82,
+		0x02, 0x05, 0x6a, 0x00, // WBXML 1.2, SI 1.0, UTF-8, no string table
+		0x45, 0x86, 0x0b, 0x03, 'a', 0x00, 0x01, 0x01, // <si><indication href="a"/></si>
+	}
+	p, err = UnmarshalPush(si)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ContentType() != "application/vnd.wap.sic" || p.ApplicationID() != "x-wap-application:wml.ua" {
+		t.Errorf("got headers %v", p.Headers)
+	}
+	if p.Message != nil || p.ServiceIndication == nil || p.ServiceIndication.Href != "a" {
+		t.Errorf("got message %v, si %v", p.Message, p.ServiceIndication)
+	}
+
+	// A Confirmed-Push is structurally a Push.
+	packet[0], packet[1] = 0x2a, byte(WSPConfirmedPush)
+	p, err = UnmarshalPush(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != WSPConfirmedPush || p.TransactionID != 0x2a || p.Message == nil {
+		t.Errorf("got type %s, transaction id %d, message %v", p.Type, p.TransactionID, p.Message)
+	}
+
+	// Truncated header values are rejected.
+	bad := []byte{0x01, 0x06, 0x03, 0xbe, 0x8d, 0x05, 0x8c, 0x82}
+	if _, err := UnmarshalPush(bad); err == nil {
+		t.Errorf("expected error for truncated header value")
+	}
+}
@@ -0,0 +1,163 @@
+package wap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	siContentType     = "application/vnd.wap.sic"
+	siTextContentType = "text/vnd.wap.si"
+)
+
+// SI is a Service Indication, as defined by WAP-167.
+type SI struct {
+	// Href is the URI of the service the indication points to.
+	Href string
+
+	// ID identifies the indication so a later one can replace it.
+	// It defaults to Href.
+	ID string
+
+	// Action is one of signal-none, signal-low, signal-medium,
+	// signal-high or delete. It defaults to signal-medium.
+	Action string
+
+	// Created and Expires are zero when the indication omits them.
+	Created time.Time
+	Expires time.Time
+
+	// Text is the message shown to the user.
+	Text string
+}
+
+// UnmarshalServiceIndication decodes a WAP push carrying a Service
+// Indication, in either its WBXML (application/vnd.wap.sic) or textual
+// (text/vnd.wap.si) form.
+func UnmarshalServiceIndication(packet []byte) (*SI, error) {
+	p, err := UnmarshalPush(packet)
+	if err != nil {
+		return nil, err
+	}
+	if p.ServiceIndication == nil {
+		return nil, fmt.Errorf("%w: content type %s is not a service indication", ErrInvalidPacket, p.ContentType())
+	}
+	return p.ServiceIndication, nil
+}
+
+// decodeSI decodes a WBXML encoded SI document.
+func decodeSI(b []byte) (*SI, error) {
+	root, err := decodeWBXML(b, &siTokens)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "si" {
+		return nil, fmt.Errorf("wbxml root element is %q, not si", root.name)
+	}
+
+	for _, c := range root.children {
+		if c.name == "indication" {
+			return c.si()
+		}
+	}
+	return nil, errors.New("si has no indication element")
+}
+
+// decodeSIText decodes a textual SI document.
+func decodeSIText(b []byte) (*SI, error) {
+	var doc struct {
+		Indication struct {
+			Href    string `xml:"href,attr"`
+			ID      string `xml:"si-id,attr"`
+			Action  string `xml:"action,attr"`
+			Created string `xml:"created,attr"`
+			Expires string `xml:"si-expires,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"indication"`
+	}
+	if err := xml.Unmarshal(bytes.TrimRight(b, "\x00"), &doc); err != nil {
+		return nil, fmt.Errorf("parse si err: %w", err)
+	}
+	in := doc.Indication
+	e := wbxmlElement{
+		attrs: map[string]string{
+			"href":       in.Href,
+			"si-id":      in.ID,
+			"action":     in.Action,
+			"created":    in.Created,
+			"si-expires": in.Expires,
+		},
+		text: in.Text,
+	}
+	return e.si()
+}
+
+// si converts an indication element to an SI.
+func (e *wbxmlElement) si() (*SI, error) {
+	si := SI{
+		Href:   e.attrs["href"],
+		ID:     e.attrs["si-id"],
+		Action: e.attrs["action"],
+		Text:   strings.TrimSpace(e.text),
+	}
+	if si.ID == "" {
+		si.ID = si.Href
+	}
+	if si.Action == "" {
+		si.Action = "signal-medium"
+	}
+
+	var err error
+	if v := e.attrs["created"]; v != "" {
+		if si.Created, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid si created date %q", v)
+		}
+	}
+	if v := e.attrs["si-expires"]; v != "" {
+		if si.Expires, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid si-expires date %q", v)
+		}
+	}
+	return &si, nil
+}
+
+// siTokens holds the SI tag and attribute tokens, from WAP-167
+// section 9.
+var siTokens = wbxmlTokens{
+	tags: map[byte]string{
+		0x05: "si",
+		0x06: "indication",
+		0x07: "info",
+		0x08: "item",
+	},
+	attrStarts: map[byte][2]string{
+		0x05: {"action", "signal-none"},
+		0x06: {"action", "signal-low"},
+		0x07: {"action", "signal-medium"},
+		0x08: {"action", "signal-high"},
+		0x09: {"action", "delete"},
+		0x0a: {"created", ""},
+		0x0b: {"href", ""},
+		0x0c: {"href", "http://"},
+		0x0d: {"href", "http://www."},
+		0x0e: {"href", "https://"},
+		0x0f: {"href", "https://www."},
+		0x10: {"si-expires", ""},
+		0x11: {"si-id", ""},
+		0x12: {"class", ""},
+	},
+	attrValues: map[byte]string{
+		0x85: ".com/",
+		0x86: ".edu/",
+		0x87: ".net/",
+		0x88: ".org/",
+	},
+	dateAttrs: map[string]bool{
+		"created":    true,
+		"si-expires": true,
+	},
+}
@@ -0,0 +1,71 @@
+package wap
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshalServiceIndication(t *testing.T) {
+	// The example SI from WAP-167 appendix D, delivered as a push.
+	packet, err := os.ReadFile("../examples/wap.si-push")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := UnmarshalServiceIndication(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &SI{
+		Href:    "http://www.xyz.com/email/123/abc.wml",
+		ID:      "http://www.xyz.com/email/123/abc.wml",
+		Action:  "signal-medium",
+		Created: time.Date(1999, 6, 25, 15, 23, 15, 0, time.UTC),
+		Expires: time.Date(1999, 6, 30, 0, 0, 0, 0, time.UTC),
+		Text:    "You have 4 new emails",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("si mismatch (-want +got):\n%s", diff)
+	}
+
+	p, err := UnmarshalPush(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Message != nil || p.ServiceIndication == nil {
+		t.Errorf("push dispatched to message %v, si %v", p.Message, p.ServiceIndication)
+	}
+	if p.ApplicationID() != "x-wap-application:wml.ua" {
+		t.Errorf("application id got %q", p.ApplicationID())
+	}
+}
+
+func TestUnmarshalServiceIndicationText(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<si><indication href="https://example.com/a" action="signal-high" si-expires="2024-01-02T03:04:05Z">Hello</indication></si>`
+	packet := append([]byte{0x01, 0x06, 0x01, 0xad}, doc...)
+
+	got, err := UnmarshalServiceIndication(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SI{
+		Href:    "https://example.com/a",
+		ID:      "https://example.com/a",
+		Action:  "signal-high",
+		Expires: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Text:    "Hello",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("si mismatch (-want +got):\n%s", diff)
+	}
+
+	mmsPush := []byte{0x01, 0x06, 0x01, 0xbe, 0x8c, 0x82, 0x98, 'a', 0x00, 0x8d, 0x92}
+	if _, err := UnmarshalServiceIndication(mmsPush); err == nil {
+		t.Errorf("expected error for an mms push")
+	}
+}
@@ -0,0 +1,91 @@
+package wap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	slContentType     = "application/vnd.wap.slc"
+	slTextContentType = "text/vnd.wap.sl"
+)
+
+// SL is a Service Loading push, as defined by WAP-168.
+type SL struct {
+	// Href is the URI of the service to load.
+	Href string
+
+	// Action is one of execute-low, execute-high or cache. It
+	// defaults to execute-low.
+	Action string
+}
+
+// UnmarshalServiceLoading decodes a WAP push carrying a Service
+// Loading document, in either its WBXML (application/vnd.wap.slc) or
+// textual (text/vnd.wap.sl) form.
+func UnmarshalServiceLoading(packet []byte) (*SL, error) {
+	p, err := UnmarshalPush(packet)
+	if err != nil {
+		return nil, err
+	}
+	if p.ServiceLoading == nil {
+		return nil, fmt.Errorf("%w: content type %s is not a service loading", ErrInvalidPacket, p.ContentType())
+	}
+	return p.ServiceLoading, nil
+}
+
+// decodeSL decodes a WBXML encoded SL document.
+func decodeSL(b []byte) (*SL, error) {
+	root, err := decodeWBXML(b, &slTokens)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "sl" {
+		return nil, fmt.Errorf("wbxml root element is %q, not sl", root.name)
+	}
+	return newSL(root.attrs["href"], root.attrs["action"]), nil
+}
+
+// decodeSLText decodes a textual SL document.
+func decodeSLText(b []byte) (*SL, error) {
+	var doc struct {
+		Href   string `xml:"href,attr"`
+		Action string `xml:"action,attr"`
+	}
+	if err := xml.Unmarshal(bytes.TrimRight(b, "\x00"), &doc); err != nil {
+		return nil, fmt.Errorf("parse sl err: %w", err)
+	}
+	return newSL(doc.Href, doc.Action), nil
+}
+
+func newSL(href, action string) *SL {
+	if action == "" {
+		action = "execute-low"
+	}
+	return &SL{Href: href, Action: action}
+}
+
+// slTokens holds the SL tag and attribute tokens, from WAP-168
+// section 9.
+var slTokens = wbxmlTokens{
+	tags: map[byte]string{
+		0x05: "sl",
+	},
+	attrStarts: map[byte][2]string{
+		0x05: {"action", "execute-low"},
+		0x06: {"action", "execute-high"},
+		0x07: {"action", "cache"},
+		0x08: {"href", ""},
+		0x09: {"href", "http://"},
+		0x0a: {"href", "http://www."},
+		0x0b: {"href", "https://"},
+		0x0c: {"href", "https://www."},
+	},
+	attrValues: map[byte]string{
+		0x85: ".com/",
+		0x86: ".edu/",
+		0x87: ".net/",
+		0x88: ".org/",
+	},
+}
@@ -0,0 +1,41 @@
+package wap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshalServiceLoading(t *testing.T) {
+	packet, err := os.ReadFile("../examples/wap.sl-push")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := UnmarshalServiceLoading(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SL{
+		Href:   "http://www.example.com/app.wml",
+		Action: "execute-high",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sl mismatch (-want +got):\n%s", diff)
+	}
+
+	doc := `<sl href="https://example.org/x"/>`
+	got, err = UnmarshalServiceLoading(append([]byte{0x01, 0x06, 0x01, 0xaf}, doc...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &SL{Href: "https://example.org/x", Action: "execute-low"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sl mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := UnmarshalServiceLoading(append([]byte{0x01, 0x06, 0x01, 0xae}, 0x02, 0x05, 0x6a, 0x00, 0x45, 0x86, 0x0b, 0x03, 'a', 0x00, 0x01, 0x01)); err == nil {
+		t.Errorf("expected error for a service indication")
+	}
+}
@@ -0,0 +1,142 @@
+package wap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/psanford/gsm/sms"
+)
+
+const (
+	// WAP push is delivered to the WDP port for connectionless WSP
+	// push (2948), from the WAP gateway's connectionless port (9200).
+	wapPushPort   = 2948
+	wapSourcePort = 9200
+
+	portIELen     = 2 + 4
+	concat8IELen  = 2 + 3
+	udhLengthSize = 1
+)
+
+// SegmentForSMS splits a WAP push packet into SMS user data segments of
+// at most segmentSize bytes (140 for 8-bit data), each prefixed with a
+// User-Data-Header addressing WAP push port 2948. When the packet does
+// not fit in one segment every segment also carries an 8-bit
+// concatenation information element, with a reference number from
+// sms.NewConcatReference.
+//
+// The returned segments are ordered by sequence number and the last
+// segment may be shorter than the rest.
+func SegmentForSMS(packet []byte, segmentSize int) ([][]byte, error) {
+	if len(packet) == 0 {
+		return nil, errors.New("empty packet")
+	}
+
+	single := udhLengthSize + portIELen
+	if len(packet)+single <= segmentSize {
+		seg := make([]byte, 0, single+len(packet))
+		seg = append(seg, portIELen)
+		seg = appendPortIE(seg)
+		seg = append(seg, packet...)
+		return [][]byte{seg}, nil
+	}
+
+	overhead := udhLengthSize + portIELen + concat8IELen
+	payloadSize := segmentSize - overhead
+	if payloadSize <= 0 {
+		return nil, fmt.Errorf("segment size %d too small for %d byte user data header", segmentSize, overhead)
+	}
+
+	total := (len(packet) + payloadSize - 1) / payloadSize
+	if total > 255 {
+		return nil, fmt.Errorf("packet needs %d segments, more than the maximum of 255", total)
+	}
+
+	ref := sms.NewConcatReference()
+
+	segments := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * payloadSize
+		if end > len(packet) {
+			end = len(packet)
+		}
+		chunk := packet[i*payloadSize : end]
+
+		seg := make([]byte, 0, overhead+len(chunk))
+		seg = append(seg, portIELen+concat8IELen)
+		seg = appendPortIE(seg)
+		seg = append(seg, sms.IEConcat8, 3, ref, byte(total), byte(i+1))
+		seg = append(seg, chunk...)
+
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+func appendPortIE(b []byte) []byte {
+	return append(b, sms.IEPort16, 4,
+		byte(wapPushPort>>8), byte(wapPushPort&0xff),
+		byte(wapSourcePort>>8), byte(wapSourcePort&0xff))
+}
+
+// Reassemble joins the SMS user data segments of a concatenated WAP
+// push, the inverse of SegmentForSMS. Segments may be given in any
+// order; they are ordered by the sequence number in their 8-bit or
+// 16-bit concatenation information element, and their User-Data-Header
+// is removed. All segments must share one reference number and total,
+// and every sequence number from 1 to the total must be present
+// exactly once. A single segment without a concatenation element is
+// returned with just its header removed.
+func Reassemble(segments [][]byte) ([]byte, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("no segments")
+	}
+
+	var (
+		parts    [][]byte
+		ref      int
+		total    int
+		haveInfo bool
+	)
+	for i, seg := range segments {
+		udh, payload, err := sms.ParseUDH(seg)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", i, err)
+		}
+		concat := udh.Concat
+		if concat == nil {
+			if len(segments) == 1 {
+				return payload, nil
+			}
+			return nil, fmt.Errorf("segment %d has no concatenation header", i)
+		}
+
+		if !haveInfo {
+			ref, total, haveInfo = concat.Reference, concat.Total, true
+			if total == 0 {
+				return nil, fmt.Errorf("segment %d has a total of 0", i)
+			}
+			parts = make([][]byte, total)
+		}
+		if concat.Reference != ref || concat.Total != total {
+			return nil, fmt.Errorf("segment %d is part %d/%d of message %d, want message %d of %d parts", i, concat.Sequence, concat.Total, concat.Reference, ref, total)
+		}
+		if concat.Sequence < 1 || concat.Sequence > total {
+			return nil, fmt.Errorf("segment %d has sequence number %d out of range 1-%d", i, concat.Sequence, total)
+		}
+		if parts[concat.Sequence-1] != nil {
+			return nil, fmt.Errorf("duplicate sequence number %d", concat.Sequence)
+		}
+		parts[concat.Sequence-1] = payload
+	}
+
+	var out []byte
+	for i, p := range parts {
+		if p == nil {
+			return nil, fmt.Errorf("missing sequence number %d of %d", i+1, total)
+		}
+		out = append(out, p...)
+	}
+	return out, nil
+}
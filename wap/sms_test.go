@@ -0,0 +1,134 @@
+package wap
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSegmentForSMS(t *testing.T) {
+	packet := make([]byte, 300)
+	for i := range packet {
+		packet[i] = byte(i)
+	}
+
+	segments, err := SegmentForSMS(packet, 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 128 bytes of payload fit after the 12 byte UDH
+	if len(segments) != 3 {
+		t.Fatalf("got %d segments, want 3", len(segments))
+	}
+
+	var joined []byte
+	var ref byte
+	for i, seg := range segments {
+		if len(seg) > 140 {
+			t.Errorf("segment %d is %d bytes", i, len(seg))
+		}
+		udh := seg[:12]
+		expect := []byte{0x0b, 0x05, 0x04, 0x0b, 0x84, 0x23, 0xf0, 0x00, 0x03, udh[9], 0x03, byte(i + 1)}
+		if !bytes.Equal(udh, expect) {
+			t.Errorf("segment %d udh got %x want %x", i, udh, expect)
+		}
+		if i == 0 {
+			ref = udh[9]
+		} else if udh[9] != ref {
+			t.Errorf("segment %d reference %d != %d", i, udh[9], ref)
+		}
+		joined = append(joined, seg[12:]...)
+	}
+
+	if len(segments[2]) != 12+300-2*128 {
+		t.Errorf("last segment is %d bytes", len(segments[2]))
+	}
+	if !bytes.Equal(joined, packet) {
+		t.Fatal("reassembled payload does not match")
+	}
+
+	again, err := SegmentForSMS(packet, 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0][9] == ref {
+		t.Fatal("reference number reused for the next packet")
+	}
+
+	segments, err = SegmentForSMS(packet[:100], 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segments))
+	}
+	if !bytes.Equal(segments[0][:7], []byte{0x06, 0x05, 0x04, 0x0b, 0x84, 0x23, 0xf0}) {
+		t.Fatalf("unexpected single segment udh %x", segments[0][:7])
+	}
+
+	if _, err := SegmentForSMS(packet, 12); err == nil {
+		t.Fatal("expected error for tiny segment size")
+	}
+}
+
+func TestReassemble(t *testing.T) {
+	packet := make([]byte, 300)
+	for i := range packet {
+		packet[i] = byte(i)
+	}
+	segments, err := SegmentForSMS(packet, 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shuffled := [][]byte{segments[2], segments[0], segments[1]}
+	got, err := Reassemble(shuffled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, packet) {
+		t.Fatal("reassembled payload does not match")
+	}
+
+	single, err := SegmentForSMS(packet[:50], 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = Reassemble(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, packet[:50]) {
+		t.Fatal("single segment payload does not match")
+	}
+
+	// 16-bit reference numbers
+	seg16 := [][]byte{
+		{0x06, 0x08, 0x04, 0x12, 0x34, 0x02, 0x02, 'b'},
+		{0x06, 0x08, 0x04, 0x12, 0x34, 0x02, 0x01, 'a'},
+	}
+	got, err = Reassemble(seg16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "ab" {
+		t.Errorf("got %q want ab", got)
+	}
+
+	otherRef := append([]byte(nil), segments[1]...)
+	otherRef[9]++
+
+	bad := map[string][][]byte{
+		"missing":       {segments[0], segments[2]},
+		"duplicate":     {segments[0], segments[1], segments[1]},
+		"reference":     {segments[0], otherRef, segments[2]},
+		"no concat":     {single[0], segments[0]},
+		"truncated udh": {{0x0b, 0x05}},
+		"none":          nil,
+	}
+	for name, segs := range bad {
+		if _, err := Reassemble(segs); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -1,31 +1,199 @@
 package wap
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 
 	"github.com/psanford/gsm/mms"
 )
 
-var invalidPacket = errors.New("invalid push notification wap packet")
+// ErrInvalidPacket is returned, possibly wrapped, for a WSP packet
+// that is malformed or isn't a Push, Confirmed-Push or Reply PDU.
+var ErrInvalidPacket = errors.New("invalid push notification wap packet")
 
+// WSPType is the PDU type of a WSP packet (WAP-230 table 34).
+type WSPType byte
+
+const (
+	WSPReply         WSPType = 0x04
+	WSPPush          WSPType = 0x06
+	WSPConfirmedPush WSPType = 0x07
+)
+
+func (t WSPType) String() string {
+	switch t {
+	case WSPReply:
+		return "Reply"
+	case WSPPush:
+		return "Push"
+	case WSPConfirmedPush:
+		return "ConfirmedPush"
+	default:
+		return fmt.Sprintf("UnknownWSPType<0x%x>", byte(t))
+	}
+}
+
+const (
+	mmsContentType = "application/vnd.wap.mms-message"
+
+	// X-Mms-Message-Type with the short-integer high bit set. A bare
+	// MMS PDU always starts with this field.
+	mmsMessageTypeField = 0x8c
+)
+
+// UnmarshalPushNotification decodes the MMS PDU carried by a WAP push,
+// discarding the WSP headers. Use UnmarshalPush to keep them.
 func UnmarshalPushNotification(packet []byte) (*mms.Message, error) {
 	if len(packet) < 6 {
-		return nil, invalidPacket
+		return nil, ErrInvalidPacket
+	}
+
+	_, body, err := StripWSP(packet)
+	if err != nil {
+		return nil, err
 	}
-	wapPush := packet[1]
 
-	if wapPush != 0x06 {
-		return nil, invalidPacket
+	return mms.Unmarshal(body)
+}
+
+// StripWSP removes the WSP session layer from packet, returning the
+// content type declared by the WSP headers and the remaining body.
+//
+// Push, Confirmed-Push and Reply PDUs (WAP-230 section 8.2.4) are
+// supported. A packet that already starts with an MMS Message-Type
+// field is treated as a bare MMS PDU and returned unchanged.
+func StripWSP(packet []byte) (contentType string, mmsBody []byte, err error) {
+	headers, body, err := splitWSP(packet)
+	if err != nil {
+		return "", nil, err
+	}
+	if headers == nil {
+		return mmsContentType, body, nil
 	}
 
-	offset := packet[2]
-	offset += 3
+	contentType, err = decodeContentType(headers)
+	if err != nil {
+		return "", nil, err
+	}
+	return contentType, body, nil
+}
 
-	if int(offset) >= len(packet) {
-		return nil, invalidPacket
+// splitWSP splits a Push, Confirmed-Push or Reply PDU into its header
+// block and body. For a bare MMS PDU the header block is nil and the
+// body is packet.
+func splitWSP(packet []byte) (headers, body []byte, err error) {
+	if isBareMMS(packet) {
+		return nil, packet, nil
 	}
 
-	packet = packet[offset:]
+	if len(packet) < 3 {
+		return nil, nil, ErrInvalidPacket
+	}
 
-	return mms.Unmarshal(packet)
+	// Push = TID PDU-Type HeadersLen ContentType Headers Data
+	// ConfirmedPush = TID PDU-Type HeadersLen ContentType Headers Data
+	// Reply = TID PDU-Type Status HeadersLen ContentType Headers Data
+	offset := 2
+	switch WSPType(packet[1]) {
+	case WSPPush, WSPConfirmedPush:
+	case WSPReply:
+		offset++
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported WSP PDU type 0x%x", ErrInvalidPacket, packet[1])
+	}
+
+	headersLen, n, err := decodeUintvar(packet[offset:])
+	if err != nil {
+		return nil, nil, ErrInvalidPacket
+	}
+	offset += n
+
+	if headersLen < 1 || uint64(offset)+uint64(headersLen) > uint64(len(packet)) {
+		return nil, nil, ErrInvalidPacket
+	}
+
+	headers = packet[offset : offset+int(headersLen)]
+	body = packet[offset+int(headersLen):]
+	if len(body) == 0 {
+		return nil, nil, ErrInvalidPacket
+	}
+
+	return headers, body, nil
+}
+
+// isBareMMS reports whether packet is an MMS PDU without a WSP layer.
+// Any octet is a valid WSP transaction id, so a packet starting with
+// the Message-Type field code is only taken as bare MMS if the next
+// octet is not a WSP PDU type. Message types are all 128 or above.
+func isBareMMS(packet []byte) bool {
+	if len(packet) == 0 || packet[0] != mmsMessageTypeField {
+		return false
+	}
+	return len(packet) < 2 || packet[1] >= 0x80
+}
+
+// decodeUintvar decodes a WSP Uintvar-integer from the start of b,
+// returning the value and the number of bytes consumed.
+func decodeUintvar(b []byte) (uint32, int, error) {
+	var result uint32
+	for i := 0; i < 5 && i < len(b); i++ {
+		result <<= 7
+		result |= uint32(b[i] & 0x7f)
+		if b[i]&0x80 == 0 {
+			return result, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid uintvar")
+}
+
+// decodeContentType decodes the Content-Type value at the start of a
+// WSP header block.
+//
+//	Content-type-value = Constrained-media | Content-general-form
+//	Content-general-form = Value-length Media-type
+func decodeContentType(b []byte) (string, error) {
+	if len(b) < 1 {
+		return "", ErrInvalidPacket
+	}
+
+	first := b[0]
+	switch {
+	case first < 31:
+		l := int(first)
+		if l+1 > len(b) {
+			return "", ErrInvalidPacket
+		}
+		return decodeMedia(b[1 : 1+l])
+	case first == 31:
+		l, n, err := decodeUintvar(b[1:])
+		if err != nil || uint64(1+n)+uint64(l) > uint64(len(b)) {
+			return "", ErrInvalidPacket
+		}
+		return decodeMedia(b[1+n : 1+n+int(l)])
+	default:
+		return decodeMedia(b)
+	}
+}
+
+// decodeMedia decodes a Well-known-media short-integer or an
+// Extension-media text string. Any trailing parameters are ignored.
+func decodeMedia(b []byte) (string, error) {
+	if len(b) < 1 {
+		return "", ErrInvalidPacket
+	}
+
+	if b[0] > 127 {
+		ct, ok := mms.ContentTypeForCode(int(b[0] & 0x7f))
+		if !ok {
+			return "", fmt.Errorf("%w: unknown short content type %d", ErrInvalidPacket, b[0]&0x7f)
+		}
+		return ct, nil
+	}
+
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", ErrInvalidPacket
+	}
+	return string(b[:end]), nil
 }
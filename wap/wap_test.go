@@ -1,6 +1,7 @@
 package wap
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -35,7 +36,7 @@ func TestWapUnmarshalPushNotification(t *testing.T) {
 	header[mms.Expiry] = []mms.HeaderField{&mms.HeaderRelativeOrAbsoluteTime{
 		Relative: &relative,
 	}}
-	header[mms.From] = []mms.HeaderField{hs("+15551231234/TYPE=PLMN")}
+	header[mms.From] = []mms.HeaderField{&mms.HeaderFrom{Address: "+15551231234/TYPE=PLMN"}}
 	header[mms.MessageClass] = []mms.HeaderField{hs("personal")}
 	header[mms.MMSVersion] = []mms.HeaderField{hs("1.2")}
 	header[mms.TransactionID] = []mms.HeaderField{hs("x-x-xx-x-xxxxxx-xx-xxx-x")}
@@ -48,6 +49,10 @@ func TestWapUnmarshalPushNotification(t *testing.T) {
 
 	expectPacket := mms.Message{
 		Header: header,
+		FieldOrder: []mms.MMSField{
+			mms.MessageType, mms.TransactionID, mms.MMSVersion, mms.From,
+			mms.MessageClass, mms.MessageSize, mms.Expiry, mms.ContentLocation,
+		},
 	}
 
 	if !cmp.Equal(*m, expectPacket) {
@@ -59,3 +64,77 @@ func hs(s string) *mms.HeaderString {
 	h := mms.HeaderString(s)
 	return &h
 }
+
+func TestStripWSP(t *testing.T) {
+	body := []byte{0x8c, 0x83, 0x98, 0x61, 0x00, 0x8d, 0x92}
+
+	checks := []struct {
+		name   string
+		packet []byte
+		ct     string
+	}{
+		{
+			name:   "push",
+			packet: append([]byte{0x01, 0x06, 0x03, 0xbe, 0xaf, 0x84}, body...),
+			ct:     "application/vnd.wap.mms-message",
+		},
+		{
+			name:   "push general form",
+			packet: append([]byte{0x01, 0x06, 0x04, 0x02, 0xbe, 0x81, 0xea}, body...),
+			ct:     "application/vnd.wap.mms-message",
+		},
+		{
+			name:   "push extension media",
+			packet: append([]byte{0x01, 0x06, 0x07, 'a', '/', 'b', '-', 'c', 0x00, 0xaf}, body...),
+			ct:     "a/b-c",
+		},
+		{
+			name:   "confirmed push",
+			packet: append([]byte{0x01, 0x07, 0x03, 0xbe, 0xaf, 0x84}, body...),
+			ct:     "application/vnd.wap.mms-message",
+		},
+		{
+			// A transaction id equal to the Message-Type field code.
+			name:   "push tid 0x8c",
+			packet: append([]byte{0x8c, 0x06, 0x03, 0xbe, 0xaf, 0x84}, body...),
+			ct:     "application/vnd.wap.mms-message",
+		},
+		{
+			name:   "reply",
+			packet: append([]byte{0x01, 0x04, 0x20, 0x01, 0xbe}, body...),
+			ct:     "application/vnd.wap.mms-message",
+		},
+		{
+			name:   "bare",
+			packet: body,
+			ct:     "application/vnd.wap.mms-message",
+		},
+	}
+
+	for _, check := range checks {
+		ct, got, err := StripWSP(check.packet)
+		if err != nil {
+			t.Fatalf("%s: %s", check.name, err)
+		}
+		if ct != check.ct {
+			t.Errorf("%s: content type got %q want %q", check.name, ct, check.ct)
+		}
+		if !cmp.Equal(got, body) {
+			t.Errorf("%s: %s", check.name, cmp.Diff(got, body))
+		}
+	}
+
+	bad := [][]byte{
+		{},
+		{0x01, 0x06},
+		{0x01, 0x05, 0x01, 0xbe, 0x8c},
+		{0x01, 0x06, 0x09, 0xbe, 0x8c},
+		{0x01, 0x06, 0x01, 0xbe},
+	}
+	for _, packet := range bad {
+		_, _, err := StripWSP(packet)
+		if !errors.Is(err, ErrInvalidPacket) {
+			t.Errorf("expected ErrInvalidPacket for %x, got %v", packet, err)
+		}
+	}
+}
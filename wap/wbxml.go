@@ -0,0 +1,277 @@
+package wap
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// WBXML global tokens, from WAP-192 section 7.1.
+const (
+	wbxmlSwitchPage = 0x00
+	wbxmlEnd        = 0x01
+	wbxmlEntity     = 0x02
+	wbxmlStrI       = 0x03
+	wbxmlLiteral    = 0x04
+	wbxmlStrT       = 0x83
+	wbxmlOpaque     = 0xc3
+
+	wbxmlHasAttrs   = 0x80
+	wbxmlHasContent = 0x40
+)
+
+// wbxmlTokens holds the tag and attribute tokens of a document type's
+// code page 0.
+type wbxmlTokens struct {
+	tags map[byte]string
+
+	// attrStarts maps an attribute start token to the attribute name
+	// and the prefix of its value.
+	attrStarts map[byte][2]string
+	attrValues map[byte]string
+
+	// dateAttrs names the attributes whose OPAQUE values are dates.
+	dateAttrs map[string]bool
+}
+
+type wbxmlElement struct {
+	name     string
+	attrs    map[string]string
+	children []wbxmlElement
+	text     string
+}
+
+// wbxmlDecoder decodes the subset of WBXML used by push content such
+// as SI and SL documents: a single code page and no literal tags or
+// extensions. Errors are sticky: once one occurs every read returns
+// zero values.
+type wbxmlDecoder struct {
+	b       []byte
+	err     error
+	tokens  *wbxmlTokens
+	charset uint32
+	strtbl  []byte
+}
+
+// decodeWBXML decodes a WBXML document, returning its root element.
+//
+//	start = version publicid charset strtbl body
+//	body = *pi element *pi
+func decodeWBXML(b []byte, tokens *wbxmlTokens) (wbxmlElement, error) {
+	d := wbxmlDecoder{b: b, tokens: tokens}
+
+	d.byte() // version
+	if publicID := d.uint(); publicID == 0 {
+		d.uint() // string table index of the public id
+	}
+	d.charset = d.uint()
+	strtblLen := d.uint()
+	d.strtbl = d.bytes(int(strtblLen))
+	if d.err != nil {
+		return wbxmlElement{}, d.err
+	}
+
+	return d.element()
+}
+
+func (d *wbxmlDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.b) == 0 {
+		d.err = fmt.Errorf("%w: truncated wbxml", ErrInvalidPacket)
+		return 0
+	}
+	c := d.b[0]
+	d.b = d.b[1:]
+	return c
+}
+
+func (d *wbxmlDecoder) peek() byte {
+	if d.err != nil || len(d.b) == 0 {
+		return wbxmlEnd
+	}
+	return d.b[0]
+}
+
+// uint decodes a mb_u_int32.
+func (d *wbxmlDecoder) uint() uint32 {
+	v, n, err := decodeUintvar(d.b)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("%w: invalid wbxml integer", ErrInvalidPacket)
+	}
+	if d.err != nil {
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *wbxmlDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.b) {
+		d.err = fmt.Errorf("%w: truncated wbxml", ErrInvalidPacket)
+		return nil
+	}
+	out := d.b[:n]
+	d.b = d.b[n:]
+	return out
+}
+
+// inlineString decodes the termstr following STR_I.
+func (d *wbxmlDecoder) inlineString() string {
+	if d.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(d.b, 0)
+	if end < 0 {
+		if d.err == nil {
+			d.err = fmt.Errorf("%w: unterminated wbxml string", ErrInvalidPacket)
+		}
+		return ""
+	}
+	return d.text(d.bytes(end + 1)[:end])
+}
+
+// tableString decodes the string table reference following STR_T.
+func (d *wbxmlDecoder) tableString() string {
+	off := int(d.uint())
+	if d.err != nil {
+		return ""
+	}
+	if off >= len(d.strtbl) {
+		d.err = fmt.Errorf("%w: wbxml string table offset %d out of range", ErrInvalidPacket, off)
+		return ""
+	}
+	s := d.strtbl[off:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return d.text(s)
+}
+
+// text converts a string in the document charset to UTF-8.
+func (d *wbxmlDecoder) text(b []byte) string {
+	if d.charset == 4 { // ISO-8859-1
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	return string(b)
+}
+
+func (d *wbxmlDecoder) element() (wbxmlElement, error) {
+	tag := d.byte()
+	if d.err != nil {
+		return wbxmlElement{}, d.err
+	}
+	if tag&^(wbxmlHasAttrs|wbxmlHasContent) == wbxmlLiteral {
+		return wbxmlElement{}, fmt.Errorf("%w: unsupported wbxml literal tag", ErrInvalidPacket)
+	}
+	e := wbxmlElement{
+		name:  d.tokens.tags[tag&0x3f],
+		attrs: make(map[string]string),
+	}
+
+	if tag&wbxmlHasAttrs != 0 {
+		if err := d.attributes(e.attrs); err != nil {
+			return e, err
+		}
+	}
+
+	if tag&wbxmlHasContent != 0 {
+		for {
+			switch c := d.peek(); c {
+			case wbxmlEnd:
+				d.byte()
+				return e, d.err
+			case wbxmlStrI:
+				d.byte()
+				e.text += d.inlineString()
+			case wbxmlStrT:
+				d.byte()
+				e.text += d.tableString()
+			case wbxmlEntity:
+				d.byte()
+				e.text += string(rune(d.uint()))
+			case wbxmlOpaque:
+				d.byte()
+				e.text += d.text(d.bytes(int(d.uint())))
+			case wbxmlSwitchPage:
+				d.byte()
+				d.byte()
+			default:
+				child, err := d.element()
+				if err != nil {
+					return e, err
+				}
+				e.children = append(e.children, child)
+			}
+			if d.err != nil {
+				return e, d.err
+			}
+		}
+	}
+
+	return e, d.err
+}
+
+// attributes decodes an attribute list up to its END token.
+func (d *wbxmlDecoder) attributes(attrs map[string]string) error {
+	var name string
+	for {
+		c := d.byte()
+		if d.err != nil {
+			return d.err
+		}
+		switch {
+		case c == wbxmlEnd:
+			return nil
+		case c == wbxmlSwitchPage:
+			d.byte()
+		case c == wbxmlStrI:
+			attrs[name] += d.inlineString()
+		case c == wbxmlStrT:
+			attrs[name] += d.tableString()
+		case c == wbxmlEntity:
+			attrs[name] += string(rune(d.uint()))
+		case c == wbxmlOpaque:
+			data := d.bytes(int(d.uint()))
+			if d.tokens.dateAttrs[name] {
+				attrs[name] = opaqueDate(data)
+			} else {
+				attrs[name] += d.text(data)
+			}
+		case c < 0x80:
+			start, ok := d.tokens.attrStarts[c]
+			if !ok {
+				return fmt.Errorf("%w: unknown wbxml attribute token 0x%02x", ErrInvalidPacket, c)
+			}
+			name = start[0]
+			attrs[name] = start[1]
+		default:
+			v, ok := d.tokens.attrValues[c]
+			if !ok {
+				return fmt.Errorf("%w: unknown wbxml attribute value token 0x%02x", ErrInvalidPacket, c)
+			}
+			attrs[name] += v
+		}
+	}
+}
+
+// opaqueDate formats the OPAQUE encoding of a date as RFC 3339.
+// The date is written as BCD digits YYYYMMDDhhmmss with trailing zero
+// octets omitted.
+func opaqueDate(b []byte) string {
+	var digits [7]byte
+	copy(digits[:], b)
+	bcd := func(i int) int {
+		return int(digits[i]>>4)*10 + int(digits[i]&0x0f)
+	}
+	t := time.Date(bcd(0)*100+bcd(1), time.Month(bcd(2)), bcd(3), bcd(4), bcd(5), bcd(6), 0, time.UTC)
+	return t.Format(time.RFC3339)
+}